@@ -10,12 +10,14 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
 	"github.com/yusufkecer/body-metrics-backend/internal/config"
 	"github.com/yusufkecer/body-metrics-backend/internal/db"
 	"github.com/yusufkecer/body-metrics-backend/internal/handler"
 	"github.com/yusufkecer/body-metrics-backend/internal/middleware"
 	"github.com/yusufkecer/body-metrics-backend/internal/repository"
 	"github.com/yusufkecer/body-metrics-backend/internal/service"
+	"github.com/yusufkecer/body-metrics-backend/internal/storage"
 )
 
 func main() {
@@ -39,28 +41,65 @@ func main() {
 	userRepo := repository.NewUserRepository(database)
 	metricRepo := repository.NewMetricRepository(database)
 	resetTokenRepo := repository.NewResetTokenRepository(database)
+	goalRepo := repository.NewGoalRepository(database)
+	measurementRepo := repository.NewMeasurementRepository(database)
+	importJobRepo := repository.NewImportJobRepository(database)
+	idempotencyRepo := repository.NewIdempotencyRepository(database)
+	syncRepo := repository.NewSyncRepository(database)
+	photoRepo := repository.NewPhotoRepository(database)
+	tagRepo := repository.NewTagRepository(database)
+	foodRepo := repository.NewFoodRepository(database)
+	activityRepo := repository.NewActivityRepository(database)
+
+	blobStore, err := storage.NewLocal(cfg.StorageDir)
+	if err != nil {
+		log.Fatalf("blob storage setup failed: %v", err)
+	}
+
+	if err := importJobRepo.FailInterrupted(); err != nil {
+		log.Printf("failed to reset interrupted imports: %v", err)
+	}
+
+	if !calc.PediatricReferenceLoaded() {
+		log.Printf("CDC BMI-for-age table missing or incomplete; profiles under 20 get no body_metric")
+	}
 
 	log.Printf("email config — from:%q resend_key_set:%v", cfg.EmailFrom, cfg.ResendAPIKey != "")
 
 	emailService := service.NewEmailService(cfg.ResendAPIKey, cfg.EmailFrom)
+	goalTracker := service.NewGoalTracker(goalRepo, metricRepo)
+	importService := service.NewImportService(importJobRepo, metricRepo, measurementRepo, goalTracker)
 
 	authHandler := handler.NewAuthHandler(cfg.JWTSecret, accountRepo, resetTokenRepo, emailService)
-	userHandler := handler.NewUserHandler(userRepo)
-	metricHandler := handler.NewMetricHandler(metricRepo, userRepo)
+	userHandler := handler.NewUserHandler(userRepo, cfg.RequireIfMatch)
+	metricHandler := handler.NewMetricHandler(metricRepo, userRepo, goalTracker, cfg.RequireIfMatch)
+	goalHandler := handler.NewGoalHandler(goalRepo, metricRepo, userRepo)
+	measurementHandler := handler.NewMeasurementHandler(measurementRepo, metricRepo, userRepo)
+	energyHandler := handler.NewEnergyHandler(userRepo, metricRepo, measurementRepo, goalRepo, activityRepo)
+	reportHandler := handler.NewReportHandler(userRepo, metricRepo, measurementRepo, goalRepo)
+	importHandler := handler.NewImportHandler(importService, importJobRepo, userRepo)
+	fhirHandler := handler.NewFHIRHandler(importService, metricRepo, userRepo)
+	syncHandler := handler.NewSyncHandler(syncRepo, goalTracker)
+	photoHandler := handler.NewPhotoHandler(photoRepo, metricRepo, userRepo, blobStore)
+	avatarHandler := handler.NewAvatarHandler(userRepo, blobStore, cfg.RequireIfMatch)
+	tagHandler := handler.NewTagHandler(tagRepo, userRepo)
+	foodHandler := handler.NewFoodHandler(foodRepo, metricRepo, userRepo)
+	activityHandler := handler.NewActivityHandler(activityRepo, metricRepo, userRepo)
 
 	loginRL := middleware.NewRateLimiter(5, 15*time.Minute)
+	idempotency := middleware.NewIdempotency(idempotencyRepo, 24*time.Hour, "import-file")
 	forgotPasswordRL := middleware.NewRateLimiter(3, 60*time.Minute)
 
 	r := mux.NewRouter()
 
 	r.Use(middleware.CORSMiddleware(cfg.AllowedOrigins))
 	r.Use(middleware.SecurityHeaders)
-	r.Use(func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
-			next.ServeHTTP(w, r)
-		})
-	})
+	r.Use(middleware.BodyLimit(1<<20, map[string]int64{
+		"import-file":   1 << 30,
+		"import-fhir":   32 << 20,
+		"photo-upload":  handler.MaxPhotoUpload,
+		"avatar-upload": handler.MaxAvatarUpload,
+	}))
 
 	r.HandleFunc("/api/v1/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -79,13 +118,62 @@ func main() {
 
 	protected := api.NewRoute().Subrouter()
 	protected.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	protected.Use(idempotency.Middleware)
 
 	protected.HandleFunc("/users", userHandler.Create).Methods(http.MethodPost, http.MethodOptions)
 	protected.HandleFunc("/users", userHandler.GetAll).Methods(http.MethodGet, http.MethodOptions)
 	protected.HandleFunc("/users/{id}", userHandler.GetByID).Methods(http.MethodGet, http.MethodOptions)
 	protected.HandleFunc("/users/{id}", userHandler.Update).Methods(http.MethodPatch, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/avatar", avatarHandler.Put).Methods(http.MethodPut, http.MethodOptions).Name("avatar-upload")
+	protected.HandleFunc("/users/{id}/avatar", avatarHandler.Get).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/avatar", avatarHandler.Delete).Methods(http.MethodDelete, http.MethodOptions)
 	protected.HandleFunc("/users/{id}/metrics", metricHandler.Create).Methods(http.MethodPost, http.MethodOptions)
 	protected.HandleFunc("/users/{id}/metrics", metricHandler.GetByUserID).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/metrics/{metricId:[0-9]+}", metricHandler.GetByID).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/metrics/{metricId:[0-9]+}", metricHandler.Update).Methods(http.MethodPatch, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/metrics/{metricId:[0-9]+}", metricHandler.Delete).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/metrics/summary", metricHandler.Summary).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/metrics/trend", metricHandler.Trend).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/tags", tagHandler.Create).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/tags", tagHandler.GetByUserID).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/tags/{tagId:[0-9]+}", tagHandler.Delete).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/report.pdf", reportHandler.PDF).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/metrics/export", metricHandler.Export).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/metrics/import", importHandler.CSV).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/imports/{source:apple-health|google-fit|withings}", importHandler.File).Methods(http.MethodPost, http.MethodOptions).Name("import-file")
+	protected.HandleFunc("/users/{id}/imports/{jobId:[0-9]+}", importHandler.GetJob).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/fhir", fhirHandler.Export).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/fhir", fhirHandler.Import).Methods(http.MethodPost, http.MethodOptions).Name("import-fhir")
+	protected.HandleFunc("/users/{id}/photos", photoHandler.Upload).Methods(http.MethodPost, http.MethodOptions).Name("photo-upload")
+	protected.HandleFunc("/users/{id}/photos", photoHandler.GetByUserID).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/photos/{photoId:[0-9]+}", photoHandler.GetByID).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/photos/{photoId:[0-9]+}", photoHandler.Delete).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/photos/{photoId:[0-9]+}/{variant:image|thumbnail}", photoHandler.Image).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/goals", goalHandler.Create).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/goals", goalHandler.GetByUserID).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/goals/forecast", goalHandler.Forecast).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/goals/{goalId:[0-9]+}", goalHandler.GetByID).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/goals/{goalId:[0-9]+}", goalHandler.Delete).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/sync", syncHandler.Sync).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/measurement-kinds", measurementHandler.Kinds).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/measurements", measurementHandler.Create).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/measurements", measurementHandler.GetByUserID).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/measurements/indicators", measurementHandler.Indicators).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/measurements/{measurementId:[0-9]+}", measurementHandler.Delete).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/energy", energyHandler.Get).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/food-log", foodHandler.Create).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/food-log", foodHandler.GetByUserID).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/food-log/daily", foodHandler.Daily).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/food-log/maintenance", foodHandler.Maintenance).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/food-log/{entryId:[0-9]+}", foodHandler.Delete).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/workout-types", activityHandler.WorkoutTypes).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/activity/steps", activityHandler.SaveSteps).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/activity/steps", activityHandler.GetSteps).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/activity/steps/{date}", activityHandler.DeleteSteps).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/activity/workouts", activityHandler.CreateWorkout).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/activity/workouts", activityHandler.GetWorkouts).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/activity/workouts/{workoutId:[0-9]+}", activityHandler.DeleteWorkout).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/users/{id}/activity/weekly", activityHandler.Weekly).Methods(http.MethodGet, http.MethodOptions)
 
 	srv := &http.Server{
 		Addr:         ":" + cfg.Port,
@@ -3,10 +3,12 @@ module github.com/yusufkecer/body-metrics-backend
 go 1.23.0
 
 require (
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/go-sql-driver/mysql v1.8.1
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/gorilla/mux v1.8.1
 	golang.org/x/crypto v0.41.0
+	golang.org/x/image v0.24.0
 )
 
 require filippo.io/edwards25519 v1.1.0 // indirect
@@ -0,0 +1,130 @@
+package calc
+
+import (
+	"math"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	// A workout minute counts as this many steps when activity is turned
+	// into a TDEE activity level; brisk walking is about 100 steps a minute.
+	stepsPerWorkoutMinute = 100
+
+	// The logged activity level needs this many days with a step count.
+	minActivityDays = 7
+)
+
+// activityLevels maps equivalent daily steps to TDEE activity levels, after
+// the Tudor-Locke step-count categories.
+var activityLevels = []struct {
+	below int
+	level string
+}{
+	{5000, domain.ActivitySedentary},
+	{7500, domain.ActivityLight},
+	{10000, domain.ActivityModerate},
+	{12500, domain.ActivityActive},
+	{math.MaxInt, domain.ActivityVeryActive},
+}
+
+// WorkoutCalories estimates the energy of a workout as MET × kg × hours.
+func WorkoutCalories(met, weightKG float64, minutes int) float64 {
+	return Round(met*weightKG*float64(minutes)/60, 0)
+}
+
+// ActivityLevelForSteps returns the activity level of an average day with
+// the given equivalent step count.
+func ActivityLevelForSteps(steps int) string {
+	for _, l := range activityLevels {
+		if steps < l.below {
+			return l.level
+		}
+	}
+	return domain.ActivityVeryActive
+}
+
+// EquivalentSteps adds workout minutes spread over days to an average daily
+// step count.
+func EquivalentSteps(averageSteps, workoutMinutes, days int) int {
+	if days <= 0 {
+		return averageSteps
+	}
+	return averageSteps + workoutMinutes*stepsPerWorkoutMinute/days
+}
+
+// LoggedActivityLevel derives the TDEE activity level from the steps and
+// workouts of a period of days days. It reports false when fewer than
+// minActivityDays days have a step count.
+func LoggedActivityLevel(steps []domain.StepCount, workouts []domain.Workout, days int) (string, bool) {
+	if len(steps) < minActivityDays {
+		return "", false
+	}
+	total := 0
+	for _, s := range steps {
+		total += s.Steps
+	}
+	minutes := 0
+	for _, w := range workouts {
+		minutes += w.DurationMin
+	}
+	return ActivityLevelForSteps(EquivalentSteps(total/len(steps), minutes, days)), true
+}
+
+// WeekStart returns the Monday of t's week.
+func WeekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+// WeeklyActivity sums steps and workouts per Monday-to-Sunday week for every
+// week touching from..to, oldest first. Step averages use the days that have
+// a count.
+func WeeklyActivity(steps []domain.StepCount, workouts []domain.Workout, from, to time.Time) []domain.WeeklyActivity {
+	var weeks []domain.WeeklyActivity
+	index := make(map[string]int)
+	for week := WeekStart(from); !week.After(to); week = week.AddDate(0, 0, 7) {
+		key := week.Format(DateLayout)
+		index[key] = len(weeks)
+		weeks = append(weeks, domain.WeeklyActivity{WeekStart: key})
+	}
+	weekOf := func(date string) *domain.WeeklyActivity {
+		day, err := ParseDate(date)
+		if err != nil {
+			return nil
+		}
+		i, ok := index[WeekStart(day).Format(DateLayout)]
+		if !ok {
+			return nil
+		}
+		return &weeks[i]
+	}
+
+	for _, s := range steps {
+		if w := weekOf(s.Date); w != nil {
+			w.Steps += s.Steps
+			w.StepDays++
+		}
+	}
+	for _, wo := range workouts {
+		if w := weekOf(wo.Date); w != nil {
+			w.Workouts++
+			w.WorkoutMinutes += wo.DurationMin
+			w.WorkoutCalories += wo.Calories
+		}
+	}
+
+	for i := range weeks {
+		w := &weeks[i]
+		if w.StepDays > 0 {
+			w.AverageSteps = w.Steps / w.StepDays
+		}
+		w.EquivalentSteps = EquivalentSteps(w.AverageSteps, w.WorkoutMinutes, 7)
+		if w.StepDays > 0 || w.Workouts > 0 {
+			level := ActivityLevelForSteps(w.EquivalentSteps)
+			w.ActivityLevel = &level
+		}
+	}
+	return weeks
+}
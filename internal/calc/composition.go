@@ -0,0 +1,154 @@
+package calc
+
+import (
+	"math"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	IndicatorWaistToHip    = "waist_to_hip"
+	IndicatorWaistToHeight = "waist_to_height"
+	IndicatorNavyBodyFat   = "navy_body_fat"
+	IndicatorFFMI          = "ffmi"
+)
+
+type CompositionInput struct {
+	Gender   *int
+	HeightCM *float64
+	WeightKG *float64
+	WaistCM  *float64
+	HipCM    *float64
+	NeckCM   *float64
+	BodyFat  *float64
+}
+
+type band struct {
+	upTo     float64
+	category string
+}
+
+func categorize(value float64, bands []band) string {
+	for _, b := range bands {
+		if value < b.upTo {
+			return b.category
+		}
+	}
+	return bands[len(bands)-1].category
+}
+
+// WaistToHip uses the WHO cut-offs for substantially increased metabolic risk
+// (0.90 for men, 0.85 for women).
+func WaistToHip(waist, hip float64, gender int) (float64, string) {
+	ratio := waist / hip
+	if gender == domain.GenderFemale {
+		return ratio, categorize(ratio, []band{{0.80, "low"}, {0.85, "moderate"}, {math.Inf(1), "high"}})
+	}
+	return ratio, categorize(ratio, []band{{0.90, "low"}, {1.00, "moderate"}, {math.Inf(1), "high"}})
+}
+
+func WaistToHeight(waist, height float64) (float64, string) {
+	ratio := waist / height
+	return ratio, categorize(ratio, []band{{0.40, "low"}, {0.50, "healthy"}, {0.60, "increased"}, {math.Inf(1), "high"}})
+}
+
+// NavyBodyFat implements the US Navy circumference method (Hodgdon & Beckett)
+// with all lengths in centimetres. ok is false when the circumferences can't
+// produce a meaningful logarithm.
+func NavyBodyFat(gender int, height, waist, neck, hip float64) (float64, string, bool) {
+	var bf float64
+	if gender == domain.GenderFemale {
+		if waist+hip-neck <= 0 {
+			return 0, "", false
+		}
+		bf = 495/(1.29579-0.35004*math.Log10(waist+hip-neck)+0.22100*math.Log10(height)) - 450
+	} else {
+		if waist-neck <= 0 {
+			return 0, "", false
+		}
+		bf = 495/(1.0324-0.19077*math.Log10(waist-neck)+0.15456*math.Log10(height)) - 450
+	}
+	if bf <= 0 || bf >= 75 {
+		return 0, "", false
+	}
+	return bf, BodyFatCategory(gender, bf), true
+}
+
+// BodyFatCategory follows the American Council on Exercise ranges.
+func BodyFatCategory(gender int, bf float64) string {
+	if gender == domain.GenderFemale {
+		return categorize(bf, []band{{14, "essential"}, {21, "athletic"}, {25, "fitness"}, {32, "average"}, {math.Inf(1), "obese"}})
+	}
+	return categorize(bf, []band{{6, "essential"}, {14, "athletic"}, {18, "fitness"}, {25, "average"}, {math.Inf(1), "obese"}})
+}
+
+// FFMI returns the fat-free mass index normalised to a height of 1.8 m.
+func FFMI(gender int, weight, height, bodyFat float64) (float64, string) {
+	meters := height / 100
+	lean := weight * (1 - bodyFat/100)
+	ffmi := lean/(meters*meters) + 6.1*(1.8-meters)
+	if gender == domain.GenderFemale {
+		return ffmi, categorize(ffmi, []band{{15, "below_average"}, {17, "average"}, {18, "above_average"}, {19.5, "excellent"}, {21.5, "superior"}, {math.Inf(1), "very_high"}})
+	}
+	return ffmi, categorize(ffmi, []band{{18, "below_average"}, {20, "average"}, {22, "above_average"}, {23, "excellent"}, {26, "superior"}, {math.Inf(1), "very_high"}})
+}
+
+// BodyIndicators derives every indicator the inputs allow. FFMI uses the
+// recorded body fat when there is one and falls back to the Navy estimate.
+func BodyIndicators(in CompositionInput) domain.BodyIndicators {
+	out := domain.BodyIndicators{Indicators: []domain.BodyIndicator{}, Missing: []string{}}
+	add := func(key string, value float64, unit, category, source string) {
+		out.Indicators = append(out.Indicators, domain.BodyIndicator{
+			Key: key, Value: Round(value, 2), Unit: unit, Category: category, Source: source,
+		})
+	}
+
+	known := domain.KnownGender(in.Gender)
+	gender := domain.GenderMale
+	if known {
+		gender = *in.Gender
+	}
+
+	if in.WaistCM != nil && in.HipCM != nil && known {
+		v, c := WaistToHip(*in.WaistCM, *in.HipCM, gender)
+		add(IndicatorWaistToHip, v, "ratio", c, "measured")
+	} else {
+		out.Missing = append(out.Missing, IndicatorWaistToHip)
+	}
+
+	if in.WaistCM != nil && in.HeightCM != nil {
+		v, c := WaistToHeight(*in.WaistCM, *in.HeightCM)
+		add(IndicatorWaistToHeight, v, "ratio", c, "measured")
+	} else {
+		out.Missing = append(out.Missing, IndicatorWaistToHeight)
+	}
+
+	var navy *float64
+	hip := 0.0
+	if in.HipCM != nil {
+		hip = *in.HipCM
+	}
+	hasHip := in.HipCM != nil || gender != domain.GenderFemale
+	if known && in.HeightCM != nil && in.WaistCM != nil && in.NeckCM != nil && hasHip {
+		if v, c, ok := NavyBodyFat(gender, *in.HeightCM, *in.WaistCM, *in.NeckCM, hip); ok {
+			navy = &v
+			add(IndicatorNavyBodyFat, v, "%", c, "estimated")
+		}
+	}
+	if navy == nil {
+		out.Missing = append(out.Missing, IndicatorNavyBodyFat)
+	}
+
+	bodyFat, source := in.BodyFat, "measured"
+	if bodyFat == nil {
+		bodyFat, source = navy, "estimated"
+	}
+	if known && in.WeightKG != nil && in.HeightCM != nil && bodyFat != nil {
+		v, c := FFMI(gender, *in.WeightKG, *in.HeightCM, *bodyFat)
+		add(IndicatorFFMI, v, "kg/m2", c, source)
+	} else {
+		out.Missing = append(out.Missing, IndicatorFFMI)
+	}
+
+	return out
+}
@@ -0,0 +1,223 @@
+package calc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func TestWaistToHip(t *testing.T) {
+	tests := []struct {
+		name       string
+		waist, hip float64
+		gender     int
+		wantRatio  float64
+		wantBand   string
+	}{
+		{"male low", 85, 100, domain.GenderMale, 0.85, "low"},
+		{"male at 0.90", 90, 100, domain.GenderMale, 0.90, "moderate"},
+		{"male below 1.00", 99, 100, domain.GenderMale, 0.99, "moderate"},
+		{"male at 1.00", 100, 100, domain.GenderMale, 1.00, "high"},
+		{"female low", 70, 100, domain.GenderFemale, 0.70, "low"},
+		{"female at 0.80", 80, 100, domain.GenderFemale, 0.80, "moderate"},
+		{"female at 0.85", 85, 100, domain.GenderFemale, 0.85, "high"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ratio, band := WaistToHip(tt.waist, tt.hip, tt.gender)
+			if !approx(ratio, tt.wantRatio, 1e-9) || band != tt.wantBand {
+				t.Errorf("WaistToHip(%v, %v) = %v, %q; want %v, %q", tt.waist, tt.hip, ratio, band, tt.wantRatio, tt.wantBand)
+			}
+		})
+	}
+}
+
+func TestWaistToHeight(t *testing.T) {
+	tests := []struct {
+		waist, height float64
+		wantRatio     float64
+		wantBand      string
+	}{
+		{60, 160, 0.375, "low"},
+		{64, 160, 0.40, "healthy"},
+		{79, 160, 0.49375, "healthy"},
+		{80, 160, 0.50, "increased"},
+		{96, 160, 0.60, "high"},
+	}
+	for _, tt := range tests {
+		ratio, band := WaistToHeight(tt.waist, tt.height)
+		if !approx(ratio, tt.wantRatio, 1e-9) || band != tt.wantBand {
+			t.Errorf("WaistToHeight(%v, %v) = %v, %q; want %v, %q", tt.waist, tt.height, ratio, band, tt.wantRatio, tt.wantBand)
+		}
+	}
+}
+
+// Expected values follow the Hodgdon & Beckett (1984) metric equations used
+// by the US Navy, rounded to 0.1 %.
+func TestNavyBodyFat(t *testing.T) {
+	tests := []struct {
+		name                     string
+		gender                   int
+		height, waist, neck, hip float64
+		want                     float64
+		wantBand                 string
+		wantOK                   bool
+	}{
+		{"male", domain.GenderMale, 178, 96, 50, 0, 15.7, "fitness", true},
+		{"male average", domain.GenderMale, 180, 85, 38, 0, 16.1, "fitness", true},
+		{"male lean", domain.GenderMale, 175, 70, 40, 0, 1.1, "essential", true},
+		{"female", domain.GenderFemale, 165, 75, 33, 100, 29.4, "average", true},
+		{"female fitness", domain.GenderFemale, 168, 70, 32, 95, 24.1, "fitness", true},
+		{"neck wider than waist", domain.GenderMale, 180, 40, 45, 0, 0, "", false},
+		{"non-positive estimate", domain.GenderMale, 180, 60, 45, 0, 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bf, band, ok := NavyBodyFat(tt.gender, tt.height, tt.waist, tt.neck, tt.hip)
+			if ok != tt.wantOK {
+				t.Fatalf("NavyBodyFat ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if Round(bf, 1) != tt.want || band != tt.wantBand {
+				t.Errorf("NavyBodyFat = %v, %q; want %v, %q", bf, band, tt.want, tt.wantBand)
+			}
+		})
+	}
+}
+
+func TestBodyFatCategoryEdges(t *testing.T) {
+	tests := []struct {
+		gender int
+		bf     float64
+		want   string
+	}{
+		{domain.GenderMale, 5.9, "essential"},
+		{domain.GenderMale, 6, "athletic"},
+		{domain.GenderMale, 18, "average"},
+		{domain.GenderMale, 25, "obese"},
+		{domain.GenderFemale, 13.9, "essential"},
+		{domain.GenderFemale, 14, "athletic"},
+		{domain.GenderFemale, 25, "average"},
+		{domain.GenderFemale, 32, "obese"},
+	}
+	for _, tt := range tests {
+		if got := BodyFatCategory(tt.gender, tt.bf); got != tt.want {
+			t.Errorf("BodyFatCategory(%d, %v) = %q, want %q", tt.gender, tt.bf, got, tt.want)
+		}
+	}
+}
+
+// FFMI follows Kouri et al. (1995): lean mass / height² + 6.1 × (1.8 − height).
+func TestFFMI(t *testing.T) {
+	tests := []struct {
+		name                    string
+		gender                  int
+		weight, height, bodyFat float64
+		want                    float64
+		wantBand                string
+	}{
+		{"at 1.8 m", domain.GenderMale, 80, 180, 15, 20.99, "above_average"},
+		{"normalised", domain.GenderMale, 70, 175, 20, 18.59, "average"},
+		{"male below 20", domain.GenderMale, 64.7, 180, 0, 19.97, "average"},
+		{"male above 20", domain.GenderMale, 64.9, 180, 0, 20.03, "above_average"},
+		{"female", domain.GenderFemale, 60, 165, 25, 17.44, "above_average"},
+		{"female below 15", domain.GenderFemale, 48.5, 180, 0, 14.97, "below_average"},
+		{"female above 15", domain.GenderFemale, 48.7, 180, 0, 15.03, "average"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ffmi, band := FFMI(tt.gender, tt.weight, tt.height, tt.bodyFat)
+			if Round(ffmi, 2) != tt.want || band != tt.wantBand {
+				t.Errorf("FFMI = %v, %q; want %v, %q", ffmi, band, tt.want, tt.wantBand)
+			}
+		})
+	}
+}
+
+func TestBodyIndicators(t *testing.T) {
+	male, female, other := domain.GenderMale, domain.GenderFemale, 2
+	f := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name        string
+		in          CompositionInput
+		wantKeys    []string
+		wantMissing []string
+		wantFFMISrc string
+	}{
+		{
+			name:        "no inputs",
+			in:          CompositionInput{},
+			wantMissing: []string{IndicatorWaistToHip, IndicatorWaistToHeight, IndicatorNavyBodyFat, IndicatorFFMI},
+		},
+		{
+			name:        "waist and height without gender",
+			in:          CompositionInput{HeightCM: f(180), WaistCM: f(85), HipCM: f(100), NeckCM: f(38)},
+			wantKeys:    []string{IndicatorWaistToHeight},
+			wantMissing: []string{IndicatorWaistToHip, IndicatorNavyBodyFat, IndicatorFFMI},
+		},
+		{
+			name:        "unknown gender value",
+			in:          CompositionInput{Gender: &other, HeightCM: f(180), WeightKG: f(80), WaistCM: f(85), HipCM: f(100), NeckCM: f(38)},
+			wantKeys:    []string{IndicatorWaistToHeight},
+			wantMissing: []string{IndicatorWaistToHip, IndicatorNavyBodyFat, IndicatorFFMI},
+		},
+		{
+			name:        "male estimates body fat without hip",
+			in:          CompositionInput{Gender: &male, HeightCM: f(180), WeightKG: f(80), WaistCM: f(85), NeckCM: f(38)},
+			wantKeys:    []string{IndicatorWaistToHeight, IndicatorNavyBodyFat, IndicatorFFMI},
+			wantMissing: []string{IndicatorWaistToHip},
+			wantFFMISrc: "estimated",
+		},
+		{
+			name:        "female needs hip for body fat",
+			in:          CompositionInput{Gender: &female, HeightCM: f(165), WeightKG: f(60), WaistCM: f(75), NeckCM: f(33)},
+			wantKeys:    []string{IndicatorWaistToHeight},
+			wantMissing: []string{IndicatorWaistToHip, IndicatorNavyBodyFat, IndicatorFFMI},
+		},
+		{
+			name:        "recorded body fat wins",
+			in:          CompositionInput{Gender: &female, HeightCM: f(165), WeightKG: f(60), WaistCM: f(75), HipCM: f(100), NeckCM: f(33), BodyFat: f(25)},
+			wantKeys:    []string{IndicatorWaistToHip, IndicatorWaistToHeight, IndicatorNavyBodyFat, IndicatorFFMI},
+			wantMissing: []string{},
+			wantFFMISrc: "measured",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := BodyIndicators(tt.in)
+			var keys []string
+			for _, ind := range out.Indicators {
+				keys = append(keys, ind.Key)
+				if ind.Key == IndicatorFFMI && ind.Source != tt.wantFFMISrc {
+					t.Errorf("FFMI source = %q, want %q", ind.Source, tt.wantFFMISrc)
+				}
+			}
+			if !sameStrings(keys, tt.wantKeys) {
+				t.Errorf("indicators = %v, want %v", keys, tt.wantKeys)
+			}
+			if !sameStrings(out.Missing, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", out.Missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func approx(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package calc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func metricTime(m *domain.UserMetric) time.Time {
+	if t, err := ParseDate(m.Date); err == nil {
+		return t
+	}
+	return time.Now().UTC()
+}
+
+// DeriveBodyMetric fills BMI and body_metric from the entry's weight and height,
+// falling back to the profile height when the entry has none.
+func DeriveBodyMetric(m *domain.UserMetric, user *domain.User) {
+	if m.Height <= 0 && user.Height != nil {
+		m.Height = *user.Height
+	}
+	if m.Weight == nil || m.Height <= 0 {
+		return
+	}
+
+	m.BMI = Round(BMI(*m.Weight, m.Height), 2)
+	c := ClassifyBMI(m.BMI, user.Gender, user.BirthOfDate, metricTime(m))
+	m.BodyMetric = nil
+	if c.Category != "" {
+		m.BodyMetric = &c.Category
+	}
+	m.BMIZScore = c.ZScore
+	m.BMIPercentile = c.Percentile
+}
+
+func AnnotateBMIForAge(m *domain.UserMetric, user *domain.User) {
+	if m.BMI <= 0 {
+		return
+	}
+	c := ClassifyBMI(m.BMI, user.Gender, user.BirthOfDate, metricTime(m))
+	m.BMIZScore = c.ZScore
+	m.BMIPercentile = c.Percentile
+}
+
+// reanchorRun is the number of consecutive agreeing suspect weigh-ins that
+// replace the trusted history, for example after a real change of scale or
+// when the first entry was the typo.
+const reanchorRun = 2
+
+// DeriveHistory recomputes BMI, body_metric, the suspect flag and weight_diff
+// across a user's whole history in date order. Each weigh-in is checked with
+// DetectOutlier against the trusted entries before it, so every write path
+// flags typos the same way. weight_diff is the change from the previous entry
+// that has a weight and is not suspect.
+func DeriveHistory(metrics []domain.UserMetric, user *domain.User) {
+	order := make([]int, len(metrics))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return metricTime(&metrics[order[a]]).Before(metricTime(&metrics[order[b]]))
+	})
+
+	for _, i := range order {
+		DeriveBodyMetric(&metrics[i], user)
+	}
+	flagOutliers(metrics, order)
+
+	var prev *float64
+	for _, i := range order {
+		m := &metrics[i]
+		if m.Weight == nil {
+			continue
+		}
+		if prev == nil {
+			m.WeightDiff = nil
+		} else {
+			diff := Round(*m.Weight-*prev, 2)
+			m.WeightDiff = &diff
+		}
+		if !m.Suspect {
+			prev = m.Weight
+		}
+	}
+}
+
+// flagOutliers sets the suspect flag on the weigh-ins visited in order. The
+// trusted history has to start somewhere, so it is not final: once
+// reanchorRun suspect weigh-ins in a row agree with each other, they become
+// the trusted history, and the entries trusted since the previous anchor are
+// flagged instead when there are no more of them than the new run. Weigh-ins
+// the user confirmed are never flagged.
+func flagOutliers(metrics []domain.UserMetric, order []int) {
+	// DetectOutlier only looks at the latest outlierWindow trusted weigh-ins,
+	// so that is all that needs to be kept.
+	var trusted []domain.UserMetric
+	var anchored, run []int
+	for _, i := range order {
+		m := &metrics[i]
+		m.Suspect, m.SuspectReason = false, nil
+		if m.Weight == nil {
+			continue
+		}
+
+		reason, suspect := DetectOutlier(trusted, *m)
+		if !suspect || m.Confirmed {
+			trusted = append(trusted, *m)
+			if len(trusted) > outlierWindow {
+				trusted = trusted[1:]
+			}
+			anchored = append(anchored, i)
+			run = nil
+			continue
+		}
+		m.Suspect, m.SuspectReason = true, &reason
+		if len(run) > 0 && !agrees(metrics[run[len(run)-1]], *m) {
+			run = nil
+		}
+		run = append(run, i)
+		if len(run) < reanchorRun {
+			continue
+		}
+
+		if len(anchored) <= len(run) {
+			for _, j := range anchored {
+				if !metrics[j].Confirmed {
+					r := domain.SuspectRateOfChange
+					metrics[j].Suspect, metrics[j].SuspectReason = true, &r
+				}
+			}
+		}
+		trusted = trusted[:0]
+		for _, j := range run {
+			metrics[j].Suspect, metrics[j].SuspectReason = false, nil
+			trusted = append(trusted, metrics[j])
+		}
+		anchored, run = run, nil
+	}
+}
+
+// agrees reports whether m is a plausible next weigh-in after prev.
+func agrees(prev, m domain.UserMetric) bool {
+	prev.Suspect = false
+	_, suspect := DetectOutlier([]domain.UserMetric{prev}, m)
+	return !suspect
+}
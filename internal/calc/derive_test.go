@@ -0,0 +1,146 @@
+package calc
+
+import (
+	"testing"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func TestDeriveHistoryFlagsOutliers(t *testing.T) {
+	height := 180.0
+	user := &domain.User{Height: &height}
+	entry := func(id int64, date string, kg float64) domain.UserMetric {
+		return domain.UserMetric{ID: id, Date: date, Weight: &kg}
+	}
+
+	// Stored out of date order, as imports and sync can leave them.
+	metrics := []domain.UserMetric{
+		entry(6, "2024-05-06", 80.4),
+		entry(1, "2024-05-01", 80.0),
+		entry(2, "2024-05-02", 80.2),
+		entry(3, "2024-05-03", 8.02),
+		entry(4, "2024-05-04", 79.8),
+		entry(5, "2024-05-05", 80.1),
+	}
+	// A stale flag from an earlier recompute is cleared.
+	metrics[0].Suspect = true
+	DeriveHistory(metrics, user)
+
+	byID := make(map[int64]domain.UserMetric)
+	for _, m := range metrics {
+		byID[m.ID] = m
+	}
+	for id, m := range byID {
+		wantSuspect := id == 3
+		if m.Suspect != wantSuspect {
+			t.Errorf("metric %d suspect = %v, want %v", id, m.Suspect, wantSuspect)
+		}
+		if wantSuspect && (m.SuspectReason == nil || *m.SuspectReason != domain.SuspectRateOfChange) {
+			t.Errorf("metric %d suspect_reason = %v, want %s", id, m.SuspectReason, domain.SuspectRateOfChange)
+		}
+		if !wantSuspect && m.SuspectReason != nil {
+			t.Errorf("metric %d suspect_reason = %s, want none", id, *m.SuspectReason)
+		}
+	}
+
+	// weight_diff skips the suspect entry.
+	if d := byID[4].WeightDiff; d == nil || *d != -0.4 {
+		t.Errorf("weight_diff after the suspect entry = %v, want -0.4", d)
+	}
+	if d := byID[1].WeightDiff; d != nil {
+		t.Errorf("first weight_diff = %v, want nil", *d)
+	}
+}
+
+func TestDeriveHistoryReanchors(t *testing.T) {
+	height := 180.0
+	user := &domain.User{Height: &height}
+	entry := func(id int64, date string, kg float64) domain.UserMetric {
+		return domain.UserMetric{ID: id, Date: date, Weight: &kg}
+	}
+	tests := []struct {
+		name    string
+		metrics []domain.UserMetric
+		suspect map[int64]bool
+	}{
+		{
+			name: "first entry is the outlier",
+			metrics: []domain.UserMetric{
+				entry(1, "2024-05-01", 8.0),
+				entry(2, "2024-05-02", 80.2),
+				entry(3, "2024-05-03", 80.1),
+				entry(4, "2024-05-04", 79.9),
+			},
+			suspect: map[int64]bool{1: true},
+		},
+		{
+			name: "lasting change after a long history",
+			metrics: []domain.UserMetric{
+				entry(1, "2024-05-01", 80.0),
+				entry(2, "2024-05-02", 80.1),
+				entry(3, "2024-05-03", 79.9),
+				entry(4, "2024-05-04", 95.0),
+				entry(5, "2024-05-05", 95.2),
+				entry(6, "2024-05-06", 95.1),
+			},
+			suspect: map[int64]bool{},
+		},
+		{
+			name: "single typo does not re-anchor",
+			metrics: []domain.UserMetric{
+				entry(1, "2024-05-01", 80.0),
+				entry(2, "2024-05-02", 8.01),
+				entry(3, "2024-05-03", 80.2),
+				entry(4, "2024-05-04", 8.02),
+			},
+			suspect: map[int64]bool{2: true, 4: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			DeriveHistory(tt.metrics, user)
+			for _, m := range tt.metrics {
+				if m.Suspect != tt.suspect[m.ID] {
+					t.Errorf("metric %d suspect = %v, want %v", m.ID, m.Suspect, tt.suspect[m.ID])
+				}
+			}
+		})
+	}
+
+	metrics := []domain.UserMetric{
+		entry(1, "2024-05-01", 8.0),
+		entry(2, "2024-05-02", 80.2),
+		entry(3, "2024-05-03", 80.1),
+	}
+	DeriveHistory(metrics, user)
+	if d := metrics[1].WeightDiff; d != nil {
+		t.Errorf("weight_diff after the suspect first entry = %v, want nil", *d)
+	}
+	if d := metrics[2].WeightDiff; d == nil || *d != -0.1 {
+		t.Errorf("weight_diff = %v, want -0.1", d)
+	}
+}
+
+func TestDeriveHistoryKeepsConfirmed(t *testing.T) {
+	height := 180.0
+	user := &domain.User{Height: &height}
+	entry := func(id int64, date string, kg float64) domain.UserMetric {
+		return domain.UserMetric{ID: id, Date: date, Weight: &kg}
+	}
+	metrics := []domain.UserMetric{
+		entry(1, "2024-05-01", 80.0),
+		entry(2, "2024-05-02", 80.2),
+		entry(3, "2024-05-03", 70.0),
+		entry(4, "2024-05-04", 70.3),
+	}
+	metrics[2].Confirmed = true
+	DeriveHistory(metrics, user)
+	for _, m := range metrics {
+		if m.Suspect {
+			t.Errorf("metric %d suspect = true, want false", m.ID)
+		}
+	}
+	if d := metrics[2].WeightDiff; d == nil || *d != -10.2 {
+		t.Errorf("weight_diff of the confirmed entry = %v, want -10.2", d)
+	}
+}
@@ -0,0 +1,93 @@
+package calc
+
+import (
+	"math"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	KcalPerKg = 7700
+
+	maxDailyDeficit = 1000
+	maxDailySurplus = 500
+)
+
+var ActivityFactors = map[string]float64{
+	domain.ActivitySedentary:  1.2,
+	domain.ActivityLight:      1.375,
+	domain.ActivityModerate:   1.55,
+	domain.ActivityActive:     1.725,
+	domain.ActivityVeryActive: 1.9,
+}
+
+func AgeYears(birth, now time.Time) int {
+	age := now.Year() - birth.Year()
+	if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+		age--
+	}
+	return age
+}
+
+func MifflinStJeor(gender int, weight, height float64, age int) float64 {
+	bmr := 10*weight + 6.25*height - 5*float64(age)
+	if gender == domain.GenderFemale {
+		return bmr - 161
+	}
+	return bmr + 5
+}
+
+func KatchMcArdle(weight, bodyFat float64) float64 {
+	return 370 + 21.6*weight*(1-bodyFat/100)
+}
+
+type EnergyInput struct {
+	Gender        int
+	WeightKG      float64
+	HeightCM      float64
+	Age           int
+	BodyFat       *float64
+	ActivityLevel string
+	// WeeklyWeightChange is the kg/week the active goal still requires;
+	// nil means maintain.
+	WeeklyWeightChange *float64
+}
+
+// Energy estimates BMR and TDEE and turns the goal's required weekly change
+// into a daily calorie target, clamped to a safe deficit/surplus and never
+// below a minimum intake.
+func Energy(in EnergyInput) domain.EnergyEstimate {
+	e := domain.EnergyEstimate{
+		Age:            in.Age,
+		WeightKG:       in.WeightKG,
+		HeightCM:       in.HeightCM,
+		BodyFat:        in.BodyFat,
+		ActivityLevel:  in.ActivityLevel,
+		ActivityFactor: ActivityFactors[in.ActivityLevel],
+		BMRMifflin:     math.Round(MifflinStJeor(in.Gender, in.WeightKG, in.HeightCM, in.Age)),
+		Formula:        domain.FormulaMifflin,
+	}
+	e.BMR = e.BMRMifflin
+	if in.BodyFat != nil {
+		katch := math.Round(KatchMcArdle(in.WeightKG, *in.BodyFat))
+		e.BMRKatch = &katch
+		e.BMR = katch
+		e.Formula = domain.FormulaKatch
+	}
+	e.TDEE = math.Round(e.BMR * e.ActivityFactor)
+
+	adjustment := 0.0
+	if in.WeeklyWeightChange != nil {
+		adjustment = *in.WeeklyWeightChange * KcalPerKg / 7
+		adjustment = math.Max(-maxDailyDeficit, math.Min(maxDailySurplus, adjustment))
+	}
+
+	floor := 1500.0
+	if in.Gender == domain.GenderFemale {
+		floor = 1200
+	}
+	e.CalorieTarget = math.Round(math.Max(floor, e.TDEE+adjustment))
+	e.DailyAdjustment = e.CalorieTarget - e.TDEE
+	return e
+}
@@ -0,0 +1,93 @@
+package calc
+
+import (
+	"math"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	ForecastMethodOLS      = "ols"
+	ForecastMethodTheilSen = "theil-sen"
+
+	maxForecastDays = 5 * 365
+)
+
+// Forecast projects when the fitted line crosses the goal's target. The
+// earliest/latest dates come from the slope's confidence bounds pivoting
+// around the fitted centre of the data; a bound that points away from the
+// target leaves that side of the interval open (nil).
+func Forecast(goal domain.Goal, series []DailyValue, method string, weeks int) domain.GoalForecast {
+	f := domain.GoalForecast{
+		GoalID:     goal.ID,
+		Method:     method,
+		Weeks:      weeks,
+		Samples:    len(series),
+		Status:     domain.ForecastInsufficientData,
+		Confidence: 0.95,
+		TargetDate: goal.TargetDate,
+	}
+
+	if len(series) > 0 {
+		remaining := goal.TargetValue - series[len(series)-1].Value
+		total := goal.TargetValue - goal.StartValue
+		if remaining == 0 || math.Signbit(remaining) != math.Signbit(total) {
+			f.Status = domain.ForecastAchieved
+			return f
+		}
+	}
+
+	if len(series) < 3 {
+		return f
+	}
+
+	fit, ok := FitLine(series)
+	if method == ForecastMethodTheilSen {
+		fit, ok = FitTheilSen(series)
+	}
+	if !ok {
+		return f
+	}
+
+	weekly := Round(fit.Slope*7, 3)
+	f.WeeklyRate = &weekly
+
+	origin := series[0].Date
+	last := series[len(series)-1].Date
+	lastX := float64(DaysBetween(origin, last))
+
+	crossing := func(slope float64) *string {
+		needed := goal.TargetValue - fit.MeanY
+		if slope == 0 || math.Signbit(needed) != math.Signbit(slope) {
+			return nil
+		}
+		x := math.Max(fit.MeanX+needed/slope, lastX)
+		if x-lastX > maxForecastDays {
+			return nil
+		}
+		s := origin.AddDate(0, 0, int(math.Ceil(x))).Format(DateLayout)
+		return &s
+	}
+
+	f.ProjectedDate = crossing(fit.Slope)
+	if f.ProjectedDate == nil {
+		f.Status = domain.ForecastNotConverging
+		return f
+	}
+	f.Status = domain.ForecastConverging
+
+	// The steeper bound reaches the target first.
+	steep, shallow := fit.SlopeHigh, fit.SlopeLow
+	if math.Abs(fit.SlopeLow) > math.Abs(fit.SlopeHigh) {
+		steep, shallow = fit.SlopeLow, fit.SlopeHigh
+	}
+	f.EarliestDate = crossing(steep)
+	f.LatestDate = crossing(shallow)
+
+	if target, err := ParseDate(goal.TargetDate); err == nil {
+		projected, _ := ParseDate(*f.ProjectedDate)
+		before := !projected.After(target)
+		f.BeforeTarget = &before
+	}
+	return f
+}
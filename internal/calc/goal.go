@@ -0,0 +1,72 @@
+package calc
+
+import (
+	"math"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const RecentWindowDays = 28
+
+func GoalSeries(goalType string, metrics []domain.UserMetric) []DailyValue {
+	if goalType == domain.GoalTypeBMI {
+		return DailyBMIs(metrics)
+	}
+	return DailyWeights(metrics)
+}
+
+// GoalProgress measures how far the series has moved from the goal's start
+// value towards its target, and compares the weekly rate still required with
+// the rate observed over the last four weeks.
+func GoalProgress(goal domain.Goal, series []DailyValue, now time.Time) domain.GoalProgress {
+	current := goal.StartValue
+	if len(series) > 0 {
+		current = series[len(series)-1].Value
+	}
+
+	total := goal.TargetValue - goal.StartValue
+	remaining := goal.TargetValue - current
+	reached := total == 0 || remaining == 0 || math.Signbit(remaining) != math.Signbit(total)
+
+	percent := 100.0
+	if total != 0 {
+		percent = math.Max(0, math.Min(100, (current-goal.StartValue)/total*100))
+	}
+	if reached {
+		percent = 100
+	}
+
+	p := domain.GoalProgress{
+		CurrentValue:    Round(current, 2),
+		PercentComplete: Round(percent, 1),
+		RemainingDelta:  Round(remaining, 2),
+		Reached:         reached,
+		OnTrack:         reached,
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if target, err := ParseDate(goal.TargetDate); err == nil {
+		p.DaysRemaining = DaysBetween(today, target)
+	}
+
+	if fit, ok := FitLine(Since(series, today.AddDate(0, 0, -RecentWindowDays))); ok {
+		weekly := Round(fit.Slope*7, 3)
+		p.RecentWeeklyRate = &weekly
+	}
+
+	if reached {
+		return p
+	}
+
+	if p.DaysRemaining > 0 {
+		required := Round(remaining/(float64(p.DaysRemaining)/7), 3)
+		p.RequiredWeeklyRate = &required
+	}
+
+	if p.RecentWeeklyRate != nil && p.DaysRemaining > 0 {
+		projected := current + *p.RecentWeeklyRate*float64(p.DaysRemaining)/7
+		p.OnTrack = math.Signbit(goal.TargetValue-projected) != math.Signbit(remaining) || projected == goal.TargetValue
+	}
+	return p
+}
@@ -0,0 +1,128 @@
+package calc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+// linearSeries returns n daily values from 2024-05-01 starting at start and
+// changing by perDay each day.
+func linearSeries(n int, start, perDay float64) []DailyValue {
+	origin := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	series := make([]DailyValue, n)
+	for i := range series {
+		series[i] = DailyValue{Date: origin.AddDate(0, 0, i), Value: start + perDay*float64(i)}
+	}
+	return series
+}
+
+func TestGoalProgress(t *testing.T) {
+	now := time.Date(2024, 5, 15, 9, 0, 0, 0, time.UTC)
+	loss := domain.Goal{Type: domain.GoalTypeWeight, StartValue: 90, TargetValue: 85, TargetDate: "2024-07-14"}
+	gain := domain.Goal{Type: domain.GoalTypeWeight, StartValue: 60, TargetValue: 65, TargetDate: "2024-07-14"}
+
+	tests := []struct {
+		name                string
+		goal                domain.Goal
+		series              []DailyValue
+		wantCurrent         float64
+		wantPercent         float64
+		wantRemaining       float64
+		wantReached, wantOn bool
+		wantRequired        *float64
+	}{
+		{
+			name: "on track", goal: loss, series: linearSeries(15, 90, -0.1),
+			wantCurrent: 88.6, wantPercent: 28, wantRemaining: -3.6, wantOn: true, wantRequired: ptr(-0.42),
+		},
+		{
+			name: "behind", goal: loss, series: linearSeries(15, 90, 0.05),
+			wantCurrent: 90.7, wantPercent: 0, wantRemaining: -5.7, wantOn: false, wantRequired: ptr(-0.665),
+		},
+		{
+			name: "reached loss", goal: loss, series: linearSeries(15, 86.4, -0.1),
+			wantCurrent: 85, wantPercent: 100, wantRemaining: 0, wantReached: true, wantOn: true,
+		},
+		{
+			name: "overshot gain", goal: gain, series: []DailyValue{{Date: now, Value: 66}},
+			wantCurrent: 66, wantPercent: 100, wantRemaining: -1, wantReached: true, wantOn: true,
+		},
+		{
+			name: "no data", goal: loss, series: nil,
+			wantCurrent: 90, wantPercent: 0, wantRemaining: -5, wantRequired: ptr(-0.583),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := GoalProgress(tt.goal, tt.series, now)
+			if p.CurrentValue != tt.wantCurrent || p.PercentComplete != tt.wantPercent || p.RemainingDelta != tt.wantRemaining {
+				t.Errorf("current/percent/remaining = %v/%v/%v, want %v/%v/%v",
+					p.CurrentValue, p.PercentComplete, p.RemainingDelta, tt.wantCurrent, tt.wantPercent, tt.wantRemaining)
+			}
+			if p.Reached != tt.wantReached || p.OnTrack != tt.wantOn {
+				t.Errorf("reached/on_track = %v/%v, want %v/%v", p.Reached, p.OnTrack, tt.wantReached, tt.wantOn)
+			}
+			if p.DaysRemaining != 60 {
+				t.Errorf("days_remaining = %d, want 60", p.DaysRemaining)
+			}
+			switch {
+			case tt.wantRequired == nil && p.RequiredWeeklyRate != nil:
+				t.Errorf("required_weekly_rate = %v, want none", *p.RequiredWeeklyRate)
+			case tt.wantRequired != nil && (p.RequiredWeeklyRate == nil || *p.RequiredWeeklyRate != *tt.wantRequired):
+				t.Errorf("required_weekly_rate = %v, want %v", p.RequiredWeeklyRate, *tt.wantRequired)
+			}
+		})
+	}
+}
+
+func TestForecast(t *testing.T) {
+	goal := domain.Goal{ID: 3, Type: domain.GoalTypeWeight, StartValue: 90, TargetValue: 80, TargetDate: "2024-07-01"}
+
+	tests := []struct {
+		name          string
+		method        string
+		series        []DailyValue
+		wantStatus    string
+		wantProjected string
+		wantRate      float64
+	}{
+		{"ols", ForecastMethodOLS, linearSeries(15, 90, -0.5), domain.ForecastConverging, "2024-05-21", -3.5},
+		{"theil-sen", ForecastMethodTheilSen, linearSeries(15, 90, -0.5), domain.ForecastConverging, "2024-05-21", -3.5},
+		{"moving away", ForecastMethodOLS, linearSeries(15, 90, 0.5), domain.ForecastNotConverging, "", 3.5},
+		{"already reached", ForecastMethodOLS, linearSeries(15, 86, -0.5), domain.ForecastAchieved, "", 0},
+		{"too few samples", ForecastMethodOLS, linearSeries(2, 90, -0.5), domain.ForecastInsufficientData, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Forecast(goal, tt.series, tt.method, 8)
+			if f.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", f.Status, tt.wantStatus)
+			}
+			if f.Samples != len(tt.series) || f.GoalID != goal.ID {
+				t.Errorf("samples/goal_id = %d/%d", f.Samples, f.GoalID)
+			}
+			if tt.wantRate != 0 && (f.WeeklyRate == nil || *f.WeeklyRate != tt.wantRate) {
+				t.Errorf("weekly_rate = %v, want %v", f.WeeklyRate, tt.wantRate)
+			}
+			if tt.wantProjected == "" {
+				if f.ProjectedDate != nil {
+					t.Errorf("projected_date = %s, want none", *f.ProjectedDate)
+				}
+				return
+			}
+			if f.ProjectedDate == nil || *f.ProjectedDate != tt.wantProjected {
+				t.Fatalf("projected_date = %v, want %s", f.ProjectedDate, tt.wantProjected)
+			}
+			// A perfect fit has no spread, so the interval collapses onto
+			// the projection.
+			if f.EarliestDate == nil || *f.EarliestDate != tt.wantProjected || f.LatestDate == nil || *f.LatestDate != tt.wantProjected {
+				t.Errorf("interval = %v..%v, want %s", f.EarliestDate, f.LatestDate, tt.wantProjected)
+			}
+			if f.BeforeTarget == nil || !*f.BeforeTarget {
+				t.Errorf("before_target = %v, want true", f.BeforeTarget)
+			}
+		})
+	}
+}
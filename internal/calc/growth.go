@@ -0,0 +1,207 @@
+package calc
+
+import (
+	"embed"
+	"encoding/csv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	pediatricMinMonths = 24
+	pediatricMaxMonths = 240
+)
+
+// BMI-for-age uses the CDC 2000 growth chart LMS table bmiagerev.csv
+// (https://www.cdc.gov/growthcharts/data/zscore/bmiagerev.csv), embedded
+// verbatim as growthdata/bmiagerev.csv: Sex 1 = male, 2 = female, Agemos 24
+// and 24.5 to 240.5 in monthly steps. It is a work of the US government
+// (CDC/NCHS) and in the public domain. Values between rows are interpolated
+// linearly.
+//
+// A table that does not cover 24 to 240 months for both sexes in steps of at
+// most a month is rejected, and pediatric classification is then unavailable
+// (see PediatricReferenceLoaded) rather than approximated.
+//
+//go:embed growthdata
+var growthData embed.FS
+
+const bmiForAgeFile = "growthdata/bmiagerev.csv"
+
+type lmsRow struct {
+	months, l, m, s float64
+}
+
+var bmiForAge = loadBMIForAge()
+
+// PediatricReferenceLoaded reports whether the CDC BMI-for-age table is
+// embedded and complete.
+func PediatricReferenceLoaded() bool {
+	return bmiForAge != nil
+}
+
+func loadBMIForAge() map[int][]lmsRow {
+	data, err := growthData.ReadFile(bmiForAgeFile)
+	if err != nil {
+		return nil
+	}
+	tables := mustParseLMS(string(data))
+	for _, sex := range []int{1, 2} {
+		if !monthlyCoverage(tables[sex], pediatricMinMonths, pediatricMaxMonths) {
+			return nil
+		}
+	}
+	return tables
+}
+
+// mustParseLMS reads the Sex, Agemos, L, M and S columns of a bmiagerev.csv
+// style table. Further columns and repeated header rows are skipped.
+func mustParseLMS(data string) map[int][]lmsRow {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		panic("calc: invalid growth reference: " + err.Error())
+	}
+
+	tables := make(map[int][]lmsRow)
+	for _, rec := range records {
+		if len(rec) < 5 || strings.EqualFold(strings.TrimSpace(rec[0]), "sex") {
+			continue
+		}
+		var v [5]float64
+		for i := range v {
+			if v[i], err = strconv.ParseFloat(strings.TrimSpace(rec[i]), 64); err != nil {
+				panic("calc: invalid growth reference value: " + err.Error())
+			}
+		}
+		sex := int(v[0])
+		tables[sex] = append(tables[sex], lmsRow{months: v[1], l: v[2], m: v[3], s: v[4]})
+	}
+	for _, rows := range tables {
+		sort.Slice(rows, func(i, j int) bool { return rows[i].months < rows[j].months })
+	}
+	return tables
+}
+
+// monthlyCoverage reports whether rows span from..to with no gap wider than
+// a month.
+func monthlyCoverage(rows []lmsRow, from, to float64) bool {
+	if len(rows) == 0 || rows[0].months > from || rows[len(rows)-1].months < to {
+		return false
+	}
+	for i := 1; i < len(rows); i++ {
+		if rows[i].months-rows[i-1].months > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func lmsAt(rows []lmsRow, months float64) lmsRow {
+	i := sort.Search(len(rows), func(i int) bool { return rows[i].months >= months })
+	if i == 0 {
+		return rows[0]
+	}
+	if i == len(rows) {
+		return rows[len(rows)-1]
+	}
+	a, b := rows[i-1], rows[i]
+	t := (months - a.months) / (b.months - a.months)
+	lerp := func(x, y float64) float64 { return x + t*(y-x) }
+	return lmsRow{months: months, l: lerp(a.l, b.l), m: lerp(a.m, b.m), s: lerp(a.s, b.s)}
+}
+
+func AgeMonths(birth, at time.Time) float64 {
+	return at.Sub(birth).Hours() / 24 / (365.25 / 12)
+}
+
+// BMIForAge returns the LMS z-score and percentile of a BMI for a child aged
+// 2 to 20 years. ok is false outside that range.
+func BMIForAge(gender int, ageMonths, bmi float64) (z, percentile float64, ok bool) {
+	if ageMonths < pediatricMinMonths || ageMonths >= pediatricMaxMonths || bmi <= 0 {
+		return 0, 0, false
+	}
+	sex := 1
+	if gender == domain.GenderFemale {
+		sex = 2
+	}
+	rows := bmiForAge[sex]
+	if len(rows) == 0 {
+		return 0, 0, false
+	}
+
+	p := lmsAt(rows, ageMonths)
+	if p.l == 0 {
+		z = math.Log(bmi/p.m) / p.s
+	} else {
+		z = (math.Pow(bmi/p.m, p.l) - 1) / (p.l * p.s)
+	}
+	percentile = 50 * (1 + math.Erf(z/math.Sqrt2))
+	return z, percentile, true
+}
+
+// ClassifyBMI picks the CDC BMI-for-age percentile cut-offs for profiles under
+// 20 with a known birth date and gender, and the adult WHO cut-offs for
+// everyone 20 or older or of unknown age. Adult bands never apply to a known
+// minor: children under 2, or minors whose gender is unknown, get an empty
+// Category.
+func ClassifyBMI(bmi float64, gender *int, birthOfDate *string, at time.Time) domain.BMIClassification {
+	if birthOfDate != nil {
+		if birth, err := ParseDate(*birthOfDate); err == nil {
+			months := AgeMonths(birth, at)
+			if months < pediatricMaxMonths {
+				if !domain.KnownGender(gender) {
+					return domain.BMIClassification{Pediatric: true}
+				}
+				z, pct, ok := BMIForAge(*gender, months, bmi)
+				if !ok {
+					return domain.BMIClassification{Pediatric: true}
+				}
+				c := domain.BMIClassification{
+					Pediatric:  true,
+					ZScore:     ptr(Round(z, 2)),
+					Percentile: ptr(Round(pct, 1)),
+				}
+				switch {
+				case pct < 5:
+					c.Category = domain.BodyMetricUnderweight
+				case pct < 85:
+					c.Category = domain.BodyMetricNormal
+				case pct < 95:
+					c.Category = domain.BodyMetricOverweight
+				default:
+					c.Category = domain.BodyMetricObese
+				}
+				return c
+			}
+		}
+	}
+
+	var c domain.BMIClassification
+	switch {
+	case bmi < 18.5:
+		c.Category = domain.BodyMetricUnderweight
+	case bmi < 25:
+		c.Category = domain.BodyMetricNormal
+	case bmi < 30:
+		c.Category = domain.BodyMetricOverweight
+	default:
+		c.Category = domain.BodyMetricObese
+	}
+	return c
+}
+
+func BMI(weightKG float64, heightCM float64) float64 {
+	meters := heightCM / 100
+	return weightKG / (meters * meters)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,94 @@
+package calc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func TestClassifyBMINeverUsesAdultBandsForMinors(t *testing.T) {
+	at := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	male, other := domain.GenderMale, 2
+	date := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		gender *int
+		birth  *string
+		bmi    float64
+		want   string
+	}{
+		{"14 months", &male, date("2025-04-01"), 17, ""},
+		{"newborn", &male, date("2026-05-01"), 14, ""},
+		{"child of unknown gender", &other, date("2018-06-01"), 17, ""},
+		{"child without gender", nil, date("2018-06-01"), 17, ""},
+		{"adult", &male, date("1990-06-01"), 24.9, domain.BodyMetricNormal},
+		{"adult at 25", &male, date("1990-06-01"), 25, domain.BodyMetricOverweight},
+		{"unknown age", nil, nil, 30, domain.BodyMetricObese},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ClassifyBMI(tt.bmi, tt.gender, tt.birth, at)
+			if c.Category != tt.want {
+				t.Errorf("ClassifyBMI category = %q, want %q", c.Category, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeriveBodyMetricLeavesInfantsUnclassified(t *testing.T) {
+	male, height, birth := domain.GenderMale, 80.0, "2025-04-01"
+	weight := 10.9
+	m := domain.UserMetric{Date: "2026-06-01", Weight: &weight, Height: height}
+	DeriveBodyMetric(&m, &domain.User{Gender: &male, BirthOfDate: &birth})
+	if m.BMI == 0 {
+		t.Fatal("BMI was not derived")
+	}
+	if m.BodyMetric != nil {
+		t.Errorf("body_metric = %q, want nil", *m.BodyMetric)
+	}
+}
+
+func TestParseLMSReadsBmiagerevLayout(t *testing.T) {
+	// Shape of the CDC file: percentile columns after S and the header row
+	// repeated before the female block. The numbers are not reference data.
+	data := "Sex,Agemos,L,M,S,P3,P5\n" +
+		"1,24,-2,16.5,0.08,14.5,14.7\n" +
+		"1,24.5,-2,16.4,0.08,14.5,14.7\n" +
+		"Sex,Agemos,L,M,S,P3,P5\n" +
+		"2,24,-1,16.4,0.08,14.2,14.4\n"
+	tables := mustParseLMS(data)
+	if len(tables[1]) != 2 || len(tables[2]) != 1 {
+		t.Fatalf("rows per sex = %d, %d; want 2, 1", len(tables[1]), len(tables[2]))
+	}
+	if got := tables[1][1]; got.months != 24.5 || got.m != 16.4 {
+		t.Errorf("second male row = %+v", got)
+	}
+}
+
+func TestMonthlyCoverage(t *testing.T) {
+	rows := func(months ...float64) []lmsRow {
+		var out []lmsRow
+		for _, m := range months {
+			out = append(out, lmsRow{months: m})
+		}
+		return out
+	}
+	tests := []struct {
+		name string
+		rows []lmsRow
+		want bool
+	}{
+		{"monthly", rows(24, 24.5, 25.5, 26.5), true},
+		{"yearly", rows(24, 36), false},
+		{"starts late", rows(24.5, 25.5, 26.5), false},
+		{"ends early", rows(24, 24.5, 25.5), false},
+		{"empty", nil, false},
+	}
+	for _, tt := range tests {
+		if got := monthlyCoverage(tt.rows, 24, 26); got != tt.want {
+			t.Errorf("%s: monthlyCoverage = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package calc
+
+import (
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	// A maintenance estimate needs a week of logging and a trend measured
+	// over at least a week; shorter periods are dominated by water weight.
+	minLoggedDays      = 7
+	minMaintenanceSpan = 7
+	maintenanceWindow  = 7
+)
+
+// DailyIntakes totals food entries per day. Entries must be ordered by date.
+func DailyIntakes(entries []domain.FoodEntry) []domain.DailyIntake {
+	var days []domain.DailyIntake
+	for _, e := range entries {
+		if len(days) == 0 || days[len(days)-1].Date != e.Date {
+			days = append(days, domain.DailyIntake{Date: e.Date, ByMeal: make(map[string]float64)})
+		}
+		d := &days[len(days)-1]
+		d.Entries++
+		d.Calories += e.Calories
+		d.ByMeal[e.MealType] += e.Calories
+		for _, pair := range []struct {
+			total *float64
+			v     *float64
+		}{{&d.Protein, e.Protein}, {&d.Carbs, e.Carbs}, {&d.Fat, e.Fat}} {
+			if pair.v != nil {
+				*pair.total += *pair.v
+			}
+		}
+	}
+	for i := range days {
+		d := &days[i]
+		d.Calories, d.Protein, d.Carbs, d.Fat = Round(d.Calories, 0), Round(d.Protein, 1), Round(d.Carbs, 1), Round(d.Fat, 1)
+		for meal, kcal := range d.ByMeal {
+			d.ByMeal[meal] = Round(kcal, 0)
+		}
+	}
+	return days
+}
+
+// Maintenance lines up daily intake with the smoothed trend weight (in kg)
+// for each day from..to and estimates maintenance calories as the average
+// logged intake minus the energy of the trend change per day. The trend is
+// computed over the whole weight history so it is settled by the start of the
+// period. Days without a log are left out of the average rather than counted
+// as zero.
+func Maintenance(intake []domain.DailyIntake, weights []DailyValue, from, to time.Time, alpha float64) domain.MaintenanceEstimate {
+	est := domain.MaintenanceEstimate{
+		From: from.Format(DateLayout),
+		To:   to.Format(DateLayout),
+	}
+
+	calories := make(map[string]float64, len(intake))
+	for _, d := range intake {
+		calories[d.Date] = d.Calories
+	}
+	points := Trend(weights, maintenanceWindow, alpha)
+
+	var sum float64
+	var start, end *DailyValue
+	next := 0
+	var trend *float64
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format(DateLayout)
+		entry := domain.IntakeDay{Date: key}
+		weighed := false
+		for next < len(weights) && !weights[next].Date.After(day) {
+			t := points[next].Trend
+			trend = &t
+			if weights[next].Date.Equal(day) {
+				w := weights[next].Value
+				entry.Weight = &w
+				weighed = true
+			}
+			next++
+		}
+		if trend != nil {
+			t := *trend
+			entry.TrendWeight = &t
+			if start == nil {
+				start = &DailyValue{Date: day, Value: t}
+			}
+			if weighed {
+				end = &DailyValue{Date: day, Value: t}
+			}
+		}
+		if kcal, ok := calories[key]; ok {
+			entry.Calories = &kcal
+			est.LoggedDays++
+			sum += kcal
+		}
+		est.Days = append(est.Days, entry)
+	}
+
+	if est.LoggedDays > 0 {
+		avg := Round(sum/float64(est.LoggedDays), 0)
+		est.AverageIntake = &avg
+	}
+	if start == nil {
+		return est
+	}
+	// The change is measured up to the last weigh-in; the trend carried past
+	// it says nothing new.
+	if end == nil {
+		end = start
+	}
+	est.TrendStart, est.TrendEnd = &start.Value, &end.Value
+
+	span := DaysBetween(start.Date, end.Date)
+	if span < minMaintenanceSpan || est.LoggedDays < minLoggedDays {
+		return est
+	}
+	change := Round(end.Value-start.Value, 2)
+	weekly := Round(change/float64(span)*7, 2)
+	maintenance := Round(*est.AverageIntake-change*KcalPerKg/float64(span), 0)
+	est.WeightChange, est.WeeklyWeightChange, est.MaintenanceCalories = &change, &weekly, &maintenance
+	return est
+}
@@ -0,0 +1,106 @@
+package calc
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	outlierWindow    = 10
+	outlierMinPoints = 5
+	// Older weigh-ins are left out of the median so a steady long-term change
+	// is not mistaken for an outlier.
+	outlierMaxAge = 30 * 24 * time.Hour
+	// 0.6745 scales the median absolute deviation to a standard deviation for
+	// normally distributed data; 3.5 is the usual modified z-score cut-off.
+	madScale     = 0.6745
+	madThreshold = 3.5
+	// Deviations below this many kilograms are normal day-to-day noise even
+	// when the history is very flat.
+	minOutlierDeltaKG = 2.0
+	// A weigh-in may differ from the previous one by 5% plus 0.5% per day in
+	// between, but never by more than 20%.
+	baseChangeRate  = 0.05
+	dailyChangeRate = 0.005
+	maxChangeRate   = 0.2
+)
+
+// DetectOutlier compares a weigh-in with the trusted entries recorded on or
+// before its date. It flags a jump from the previous weigh-in that is larger
+// than the allowed rate of change, or a value far from the median of the last
+// outlierWindow weigh-ins of the past 30 days by modified z-score.
+func DetectOutlier(history []domain.UserMetric, m domain.UserMetric) (string, bool) {
+	if m.Weight == nil {
+		return "", false
+	}
+	at, err := ParseDate(m.Date)
+	if err != nil {
+		return "", false
+	}
+
+	var prior []DailyValue
+	for _, h := range history {
+		if h.Suspect || h.Weight == nil || (m.ID != 0 && h.ID == m.ID) {
+			continue
+		}
+		t, err := ParseDate(h.Date)
+		if err != nil || t.After(at) {
+			continue
+		}
+		prior = append(prior, DailyValue{Date: t, Value: *h.Weight})
+	}
+	if len(prior) == 0 {
+		return "", false
+	}
+	sort.SliceStable(prior, func(i, j int) bool { return prior[i].Date.Before(prior[j].Date) })
+	if len(prior) > outlierWindow {
+		prior = prior[len(prior)-outlierWindow:]
+	}
+
+	weight := *m.Weight
+	last := prior[len(prior)-1]
+	days := at.Sub(last.Date).Hours() / 24
+	rate := math.Min(baseChangeRate+dailyChangeRate*days, maxChangeRate)
+	if math.Abs(weight-last.Value) > last.Value*rate {
+		return domain.SuspectRateOfChange, true
+	}
+
+	var values []float64
+	for _, p := range prior {
+		if at.Sub(p.Date) <= outlierMaxAge {
+			values = append(values, p.Value)
+		}
+	}
+	if len(values) < outlierMinPoints {
+		return "", false
+	}
+	sort.Float64s(values)
+	med := median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - med)
+	}
+	sort.Float64s(deviations)
+	mad := median(deviations)
+
+	delta := math.Abs(weight - med)
+	if delta > minOutlierDeltaKG && (mad == 0 || madScale*delta/mad > madThreshold) {
+		return domain.SuspectMAD, true
+	}
+	return "", false
+}
+
+// WithoutSuspects returns the metrics not flagged as suspect, for summaries
+// that must not be skewed by likely typos.
+func WithoutSuspects(metrics []domain.UserMetric) []domain.UserMetric {
+	out := make([]domain.UserMetric, 0, len(metrics))
+	for _, m := range metrics {
+		if !m.Suspect {
+			out = append(out, m)
+		}
+	}
+	return out
+}
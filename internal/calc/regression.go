@@ -0,0 +1,153 @@
+package calc
+
+import (
+	"math"
+	"sort"
+)
+
+type LinearFit struct {
+	Slope     float64
+	Intercept float64
+	N         int
+	MeanX     float64
+	MeanY     float64
+	// SlopeLow and SlopeHigh bound the slope at 95% confidence.
+	SlopeLow  float64
+	SlopeHigh float64
+}
+
+func xs(days []DailyValue) []float64 {
+	out := make([]float64, len(days))
+	for i, d := range days {
+		out[i] = float64(DaysBetween(days[0].Date, d.Date))
+	}
+	return out
+}
+
+// FitLine runs an ordinary least squares fit of value against days since the
+// first sample. ok is false when there are fewer than two distinct days.
+func FitLine(days []DailyValue) (LinearFit, bool) {
+	if len(days) < 2 {
+		return LinearFit{}, false
+	}
+	x := xs(days)
+
+	var sumX, sumY float64
+	for i, d := range days {
+		sumX += x[i]
+		sumY += d.Value
+	}
+	n := float64(len(days))
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy float64
+	for i, d := range days {
+		dx := x[i] - meanX
+		sxx += dx * dx
+		sxy += dx * (d.Value - meanY)
+	}
+	if sxx == 0 {
+		return LinearFit{}, false
+	}
+
+	slope := sxy / sxx
+	fit := LinearFit{
+		Slope:     slope,
+		Intercept: meanY - slope*meanX,
+		N:         len(days),
+		MeanX:     meanX,
+		MeanY:     meanY,
+		SlopeLow:  slope,
+		SlopeHigh: slope,
+	}
+
+	if len(days) > 2 {
+		var sse float64
+		for i, d := range days {
+			r := d.Value - (fit.Intercept + slope*x[i])
+			sse += r * r
+		}
+		se := math.Sqrt(sse / (n - 2) / sxx)
+		margin := tCritical95(len(days)-2) * se
+		fit.SlopeLow, fit.SlopeHigh = slope-margin, slope+margin
+	}
+	return fit, true
+}
+
+// FitTheilSen fits the median of all pairwise slopes, which is insensitive to
+// a few bad weigh-ins. The confidence bounds use Sen's rank-based interval.
+func FitTheilSen(days []DailyValue) (LinearFit, bool) {
+	if len(days) < 2 {
+		return LinearFit{}, false
+	}
+	x := xs(days)
+
+	var slopes []float64
+	for i := 0; i < len(days); i++ {
+		for j := i + 1; j < len(days); j++ {
+			if x[j] != x[i] {
+				slopes = append(slopes, (days[j].Value-days[i].Value)/(x[j]-x[i]))
+			}
+		}
+	}
+	if len(slopes) == 0 {
+		return LinearFit{}, false
+	}
+	sort.Float64s(slopes)
+	slope := median(slopes)
+
+	intercepts := make([]float64, len(days))
+	for i, d := range days {
+		intercepts[i] = d.Value - slope*x[i]
+	}
+	sort.Float64s(intercepts)
+	intercept := median(intercepts)
+
+	meanX := 0.0
+	for _, v := range x {
+		meanX += v
+	}
+	meanX /= float64(len(x))
+
+	n := float64(len(days))
+	m := float64(len(slopes))
+	c := 1.96 * math.Sqrt(n*(n-1)*(2*n+5)/18)
+	lo := int(math.Floor((m - c) / 2))
+	hi := int(math.Ceil((m + c) / 2))
+	lo = max(0, min(len(slopes)-1, lo))
+	hi = max(0, min(len(slopes)-1, hi))
+
+	return LinearFit{
+		Slope:     slope,
+		Intercept: intercept,
+		N:         len(days),
+		MeanX:     meanX,
+		MeanY:     intercept + slope*meanX,
+		SlopeLow:  slopes[lo],
+		SlopeHigh: slopes[hi],
+	}, true
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+var tTable95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func tCritical95(df int) float64 {
+	if df < 1 {
+		return math.Inf(1)
+	}
+	if df <= len(tTable95) {
+		return tTable95[df-1]
+	}
+	return 1.96
+}
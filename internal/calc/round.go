@@ -0,0 +1,8 @@
+package calc
+
+import "math"
+
+func Round(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,71 @@
+package calc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+// Summarize builds a MetricSummary in kilograms over the metrics dated on or
+// after since (all of them when since is zero).
+func Summarize(metrics []domain.UserMetric, since time.Time) domain.MetricSummary {
+	var s domain.MetricSummary
+	type dated struct {
+		at time.Time
+		m  domain.UserMetric
+	}
+	var entries []dated
+	for _, m := range metrics {
+		at, err := ParseDate(m.Date)
+		if err != nil || at.Before(since) {
+			continue
+		}
+		if m.Suspect {
+			s.SuspectCount++
+			continue
+		}
+		entries = append(entries, dated{at: at, m: m})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].at.Before(entries[j].at) })
+	s.Count = len(entries)
+	if len(entries) == 0 {
+		return s
+	}
+
+	first := entries[0].at.Format(DateLayout)
+	last := entries[len(entries)-1].at.Format(DateLayout)
+	s.FirstDate, s.LastDate = &first, &last
+
+	var sum float64
+	var weighed int
+	for _, e := range entries {
+		m := e.m
+		if m.BMI > 0 {
+			bmi := m.BMI
+			s.LatestBMI = &bmi
+			s.LatestBodyMetric = m.BodyMetric
+		}
+		if m.Weight == nil {
+			continue
+		}
+		w := *m.Weight
+		if s.StartWeight == nil {
+			s.StartWeight = ptr(w)
+		}
+		s.LatestWeight = ptr(w)
+		if s.MinWeight == nil || w < *s.MinWeight {
+			s.MinWeight = ptr(w)
+		}
+		if s.MaxWeight == nil || w > *s.MaxWeight {
+			s.MaxWeight = ptr(w)
+		}
+		sum += w
+		weighed++
+	}
+	if weighed > 0 {
+		s.AverageWeight = ptr(Round(sum/float64(weighed), 2))
+		s.WeightChange = ptr(Round(*s.LatestWeight-*s.StartWeight, 2))
+	}
+	return s
+}
@@ -0,0 +1,40 @@
+package calc
+
+import "github.com/yusufkecer/body-metrics-backend/internal/domain"
+
+// WithAnyTag returns the metrics carrying at least one of tags.
+func WithAnyTag(metrics []domain.UserMetric, tags []string) []domain.UserMetric {
+	out := make([]domain.UserMetric, 0, len(metrics))
+	for _, m := range metrics {
+		if hasAnyTag(m, tags) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// WithoutTags returns the metrics carrying none of tags, for example to keep
+// entries tagged "scale-error" out of a summary.
+func WithoutTags(metrics []domain.UserMetric, tags []string) []domain.UserMetric {
+	if len(tags) == 0 {
+		return metrics
+	}
+	out := make([]domain.UserMetric, 0, len(metrics))
+	for _, m := range metrics {
+		if !hasAnyTag(m, tags) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func hasAnyTag(m domain.UserMetric, tags []string) bool {
+	for _, t := range m.Tags {
+		for _, want := range tags {
+			if t == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package calc
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+type DailyValue struct {
+	Date  time.Time
+	Value float64
+}
+
+func DailyWeights(metrics []domain.UserMetric) []DailyValue {
+	return DailySeries(metrics, func(m domain.UserMetric) (float64, bool) {
+		if m.Weight == nil {
+			return 0, false
+		}
+		return *m.Weight, true
+	})
+}
+
+func DailyBMIs(metrics []domain.UserMetric) []DailyValue {
+	return DailySeries(metrics, func(m domain.UserMetric) (float64, bool) {
+		return m.BMI, m.BMI > 0
+	})
+}
+
+// DailySeries collapses metrics to one value per calendar day, keeping the
+// last entry recorded for that day, ordered by date.
+func DailySeries(metrics []domain.UserMetric, pick func(domain.UserMetric) (float64, bool)) []DailyValue {
+	byDay := make(map[time.Time]float64)
+	for _, m := range metrics {
+		v, ok := pick(m)
+		if !ok {
+			continue
+		}
+		day, err := ParseDate(m.Date)
+		if err != nil {
+			continue
+		}
+		byDay[day] = v
+	}
+
+	days := make([]DailyValue, 0, len(byDay))
+	for day, v := range byDay {
+		days = append(days, DailyValue{Date: day, Value: v})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	return days
+}
+
+func Since(days []DailyValue, cutoff time.Time) []DailyValue {
+	i := sort.Search(len(days), func(i int) bool { return !days[i].Date.Before(cutoff) })
+	return days[i:]
+}
+
+// Trend computes a trailing simple moving average over the last window calendar
+// days and an exponentially smoothed trend weight. Days without a weigh-in are
+// not counted as zero: the average only uses days that have data, and the
+// smoothing factor is compounded over the gap so a long break moves the trend
+// further towards the next reading.
+func Trend(days []DailyValue, window int, alpha float64) []domain.TrendPoint {
+	points := make([]domain.TrendPoint, 0, len(days))
+
+	var trend float64
+	start := 0
+	for i, d := range days {
+		if i == 0 {
+			trend = d.Value
+		} else {
+			gap := DaysBetween(days[i-1].Date, d.Date)
+			factor := 1 - math.Pow(1-alpha, float64(gap))
+			trend += factor * (d.Value - trend)
+		}
+
+		cutoff := d.Date.AddDate(0, 0, -window)
+		for !days[start].Date.After(cutoff) {
+			start++
+		}
+		var sum float64
+		for _, w := range days[start : i+1] {
+			sum += w.Value
+		}
+		samples := i + 1 - start
+
+		points = append(points, domain.TrendPoint{
+			Date:          d.Date.Format(DateLayout),
+			Weight:        d.Value,
+			MovingAverage: Round(sum/float64(samples), 2),
+			Trend:         Round(trend, 2),
+			Samples:       samples,
+		})
+	}
+	return points
+}
@@ -14,6 +14,8 @@ type Config struct {
 	ResendAPIKey   string
 	EmailFrom      string
 	AllowedOrigins string
+	RequireIfMatch bool
+	StorageDir     string
 }
 
 func Load() *Config {
@@ -29,6 +31,8 @@ func Load() *Config {
 		ResendAPIKey:   getEnv("RESEND_API_KEY", ""),
 		EmailFrom:      getEnv("EMAIL_FROM", "BodyMetrics <onboarding@resend.dev>"),
 		AllowedOrigins: getEnv("ALLOWED_ORIGINS", "*"),
+		RequireIfMatch: getEnv("REQUIRE_IF_MATCH", "false") == "true",
+		StorageDir:     getEnv("STORAGE_DIR", "data/blobs"),
 	}
 }
 
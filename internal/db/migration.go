@@ -77,6 +77,234 @@ var migrations = []migration{
 				ADD UNIQUE KEY uq_users_account_id (account_id)
 		`,
 	},
+	{
+		version: "005_create_user_goals",
+		sql: `
+			CREATE TABLE IF NOT EXISTS user_goals (
+				id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+				user_id      BIGINT UNSIGNED NOT NULL,
+				goal_type    VARCHAR(10) NOT NULL,
+				target_value DOUBLE NOT NULL,
+				start_value  DOUBLE NOT NULL,
+				start_weight DOUBLE,
+				start_bmi    DOUBLE,
+				start_date   VARCHAR(20) NOT NULL,
+				target_date  VARCHAR(20) NOT NULL,
+				status       VARCHAR(20) NOT NULL DEFAULT 'active',
+				created_at   DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at   DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				KEY idx_user_goals_user_status (user_id, status),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`,
+	},
+	{
+		version: "006_create_user_measurements",
+		sql: `
+			CREATE TABLE IF NOT EXISTS user_measurements (
+				id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+				user_id    BIGINT UNSIGNED NOT NULL,
+				kind       VARCHAR(40) NOT NULL,
+				value      DOUBLE NOT NULL,
+				unit       VARCHAR(10) NOT NULL,
+				date       VARCHAR(20) NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				KEY idx_user_measurements_user_kind (user_id, kind, date),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`,
+	},
+	{
+		version: "007_add_user_unit_preferences",
+		sql: `
+			ALTER TABLE users
+				ADD COLUMN weight_unit VARCHAR(2) NOT NULL DEFAULT 'kg' AFTER birth_of_date,
+				ADD COLUMN height_unit VARCHAR(2) NOT NULL DEFAULT 'cm' AFTER weight_unit
+		`,
+	},
+	{
+		version: "008_create_import_jobs",
+		sql: `
+			CREATE TABLE IF NOT EXISTS import_jobs (
+				id              BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+				user_id         BIGINT UNSIGNED NOT NULL,
+				source          VARCHAR(30) NOT NULL,
+				status          VARCHAR(20) NOT NULL,
+				bytes_total     BIGINT NOT NULL DEFAULT 0,
+				bytes_processed BIGINT NOT NULL DEFAULT 0,
+				records_found   INT NOT NULL DEFAULT 0,
+				error           VARCHAR(255),
+				report          MEDIUMTEXT,
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				KEY idx_import_jobs_user (user_id, id),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`,
+	},
+	{
+		version: "009_create_idempotency_keys",
+		sql: `
+			CREATE TABLE IF NOT EXISTS idempotency_keys (
+				id            BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+				account_id    BIGINT UNSIGNED NOT NULL,
+				idem_key      VARCHAR(255) NOT NULL,
+				request_hash  CHAR(64) NOT NULL,
+				status_code   INT,
+				content_type  VARCHAR(100),
+				location      VARCHAR(2048),
+				etag          VARCHAR(100),
+				response_body MEDIUMBLOB,
+				created_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+				expires_at    DATETIME NOT NULL,
+				UNIQUE KEY uq_idempotency_account_key (account_id, idem_key),
+				KEY idx_idempotency_expires (expires_at),
+				FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+			)`,
+	},
+	{
+		// Existing rows join the change feed at sequence 1 so a client's first
+		// sync (checkpoint 0) receives them. The feed is read per account, so
+		// each account has its own counter. One profile per account is now
+		// enforced for live rows only, under the account's counter lock, so a
+		// deleted profile keeps its tombstone.
+		version: "010_add_sync_metadata",
+		sql: `
+			CREATE TABLE IF NOT EXISTS sync_sequences (
+				account_id BIGINT UNSIGNED PRIMARY KEY,
+				value      BIGINT UNSIGNED NOT NULL,
+				FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+			);
+			INSERT IGNORE INTO sync_sequences (account_id, value) SELECT id, 1 FROM accounts;
+			ALTER TABLE users
+				ADD COLUMN uuid        CHAR(36) NULL,
+				ADD COLUMN version     BIGINT UNSIGNED NOT NULL DEFAULT 1,
+				ADD COLUMN modified_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+				ADD COLUMN deleted_at  DATETIME(3) NULL,
+				ADD COLUMN sync_seq    BIGINT UNSIGNED NOT NULL DEFAULT 1;
+			UPDATE users SET uuid = UUID() WHERE uuid IS NULL;
+			ALTER TABLE users
+				MODIFY COLUMN uuid CHAR(36) NOT NULL,
+				ADD UNIQUE KEY uq_users_uuid (uuid),
+				ADD KEY idx_users_sync (account_id, sync_seq);
+			ALTER TABLE users DROP INDEX uq_users_account_id;
+			ALTER TABLE user_metrics
+				ADD COLUMN uuid        CHAR(36) NULL,
+				ADD COLUMN version     BIGINT UNSIGNED NOT NULL DEFAULT 1,
+				ADD COLUMN modified_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+				ADD COLUMN deleted_at  DATETIME(3) NULL,
+				ADD COLUMN sync_seq    BIGINT UNSIGNED NOT NULL DEFAULT 1;
+			UPDATE user_metrics SET uuid = UUID() WHERE uuid IS NULL;
+			ALTER TABLE user_metrics
+				MODIFY COLUMN uuid CHAR(36) NOT NULL,
+				ADD UNIQUE KEY uq_user_metrics_uuid (uuid),
+				ADD KEY idx_user_metrics_sync (user_id, sync_seq)`,
+	},
+	{
+		version: "011_add_user_metrics_suspect",
+		sql: `
+			ALTER TABLE user_metrics
+				ADD COLUMN suspect        TINYINT(1) NOT NULL DEFAULT 0,
+				ADD COLUMN suspect_reason VARCHAR(32) NULL,
+				ADD COLUMN confirmed      TINYINT(1) NOT NULL DEFAULT 0`,
+	},
+	{
+		version: "012_create_user_photos",
+		sql: `
+			CREATE TABLE IF NOT EXISTS user_photos (
+				id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+				user_id    BIGINT UNSIGNED NOT NULL,
+				metric_id  BIGINT UNSIGNED NULL,
+				date       VARCHAR(20) NOT NULL,
+				width      INT NOT NULL,
+				height     INT NOT NULL,
+				size_bytes BIGINT NOT NULL,
+				blob_key   VARCHAR(255) NOT NULL,
+				thumb_key  VARCHAR(255) NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				KEY idx_user_photos_user_date (user_id, date),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+				FOREIGN KEY (metric_id) REFERENCES user_metrics(id) ON DELETE SET NULL
+			)`,
+	},
+	{
+		// avatar keeps the preset identifier; avatar_image names an uploaded
+		// image, which takes precedence while it is set.
+		version: "013_add_users_avatar_image",
+		sql: `
+			ALTER TABLE users
+				ADD COLUMN avatar_image CHAR(36) NULL`,
+	},
+	{
+		version: "014_add_metric_notes_and_tags",
+		sql: `
+			ALTER TABLE user_metrics
+				ADD COLUMN note VARCHAR(500) NULL;
+			CREATE TABLE IF NOT EXISTS user_tags (
+				id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+				user_id    BIGINT UNSIGNED NOT NULL,
+				name       VARCHAR(32) NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE KEY uq_user_tags_user_name (user_id, name),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			);
+			CREATE TABLE IF NOT EXISTS user_metric_tags (
+				metric_id BIGINT UNSIGNED NOT NULL,
+				tag_id    BIGINT UNSIGNED NOT NULL,
+				PRIMARY KEY (metric_id, tag_id),
+				KEY idx_user_metric_tags_tag (tag_id),
+				FOREIGN KEY (metric_id) REFERENCES user_metrics(id) ON DELETE CASCADE,
+				FOREIGN KEY (tag_id) REFERENCES user_tags(id) ON DELETE CASCADE
+			)`,
+	},
+	{
+		version: "015_create_user_food_entries",
+		sql: `
+			CREATE TABLE IF NOT EXISTS user_food_entries (
+				id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+				user_id    BIGINT UNSIGNED NOT NULL,
+				date       VARCHAR(20) NOT NULL,
+				meal_type  VARCHAR(16) NOT NULL,
+				name       VARCHAR(100) NULL,
+				calories   DOUBLE NOT NULL,
+				protein    DOUBLE NULL,
+				carbs      DOUBLE NULL,
+				fat        DOUBLE NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				KEY idx_user_food_entries_user_date (user_id, date),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`,
+	},
+	{
+		version: "016_create_activity_tables",
+		sql: `
+			CREATE TABLE IF NOT EXISTS user_step_counts (
+				id         BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+				user_id    BIGINT UNSIGNED NOT NULL,
+				date       VARCHAR(20) NOT NULL,
+				steps      INT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				UNIQUE KEY uq_user_step_counts_user_date (user_id, date),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			);
+			CREATE TABLE IF NOT EXISTS user_workouts (
+				id           BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+				user_id      BIGINT UNSIGNED NOT NULL,
+				date         VARCHAR(20) NOT NULL,
+				type         VARCHAR(32) NOT NULL,
+				duration_min INT NOT NULL,
+				met          DOUBLE NOT NULL,
+				weight_kg    DOUBLE NOT NULL,
+				calories     DOUBLE NOT NULL,
+				created_at   DATETIME DEFAULT CURRENT_TIMESTAMP,
+				KEY idx_user_workouts_user_date (user_id, date),
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`,
+	},
+	{
+		version: "017_document_user_gender",
+		sql: `
+			ALTER TABLE users
+				MODIFY COLUMN gender TINYINT NULL COMMENT '0 = male, 1 = female; other values are treated as unknown'`,
+	},
 }
 
 func RunMigrations(db *sql.DB) error {
@@ -0,0 +1,106 @@
+package domain
+
+import "time"
+
+const (
+	WorkoutWalking    = "walking"
+	WorkoutRunning    = "running"
+	WorkoutCycling    = "cycling"
+	WorkoutSwimming   = "swimming"
+	WorkoutStrength   = "strength"
+	WorkoutHIIT       = "hiit"
+	WorkoutYoga       = "yoga"
+	WorkoutHiking     = "hiking"
+	WorkoutRowing     = "rowing"
+	WorkoutElliptical = "elliptical"
+	WorkoutDancing    = "dancing"
+	WorkoutOther      = "other"
+)
+
+// WorkoutType gives the MET value (metabolic equivalent, from the Compendium
+// of Physical Activities) used to estimate a workout's energy cost.
+type WorkoutType struct {
+	Key string  `json:"key"`
+	MET float64 `json:"met"`
+}
+
+var workoutTypes = []WorkoutType{
+	{Key: WorkoutWalking, MET: 3.5},
+	{Key: WorkoutRunning, MET: 9.8},
+	{Key: WorkoutCycling, MET: 7.5},
+	{Key: WorkoutSwimming, MET: 8.0},
+	{Key: WorkoutStrength, MET: 5.0},
+	{Key: WorkoutHIIT, MET: 8.0},
+	{Key: WorkoutYoga, MET: 2.5},
+	{Key: WorkoutHiking, MET: 6.0},
+	{Key: WorkoutRowing, MET: 7.0},
+	{Key: WorkoutElliptical, MET: 5.0},
+	{Key: WorkoutDancing, MET: 5.0},
+	{Key: WorkoutOther, MET: 4.0},
+}
+
+func WorkoutTypes() []WorkoutType {
+	return append([]WorkoutType(nil), workoutTypes...)
+}
+
+func LookupWorkoutType(key string) (WorkoutType, bool) {
+	for _, t := range workoutTypes {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return WorkoutType{}, false
+}
+
+// StepCount is the step total of one day; logging a day again replaces it.
+type StepCount struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Date      string    `json:"date"`
+	Steps     int       `json:"steps"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type StepCountRequest struct {
+	Date  string `json:"date"`
+	Steps *int   `json:"steps"`
+}
+
+// Workout is one exercise session. Calories are estimated when it is logged
+// from its MET value, duration and the user's latest weight (WeightKG).
+type Workout struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Date        string    `json:"date"`
+	Type        string    `json:"type"`
+	DurationMin int       `json:"duration_min"`
+	MET         float64   `json:"met"`
+	WeightKG    float64   `json:"weight_kg"`
+	Calories    float64   `json:"calories"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkoutRequest logs a workout. MET overrides the type's default, for
+// example for a workout of type "other".
+type WorkoutRequest struct {
+	Date        string   `json:"date"`
+	Type        string   `json:"type"`
+	DurationMin *int     `json:"duration_min"`
+	MET         *float64 `json:"met"`
+}
+
+// WeeklyActivity sums one Monday-to-Sunday week. EquivalentSteps adds the
+// workouts to the daily step average, and ActivityLevel is the TDEE level it
+// maps to (nil for a week without any activity data).
+type WeeklyActivity struct {
+	WeekStart       string  `json:"week_start"`
+	Steps           int     `json:"steps"`
+	StepDays        int     `json:"step_days"`
+	AverageSteps    int     `json:"average_steps"`
+	Workouts        int     `json:"workouts"`
+	WorkoutMinutes  int     `json:"workout_minutes"`
+	WorkoutCalories float64 `json:"workout_calories"`
+	EquivalentSteps int     `json:"equivalent_steps"`
+	ActivityLevel   *string `json:"activity_level"`
+}
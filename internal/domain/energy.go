@@ -0,0 +1,36 @@
+package domain
+
+const (
+	ActivitySedentary  = "sedentary"
+	ActivityLight      = "light"
+	ActivityModerate   = "moderate"
+	ActivityActive     = "active"
+	ActivityVeryActive = "very_active"
+
+	// ActivitySource says where the activity level of an estimate came from:
+	// the request, the logged steps and workouts, or the sedentary default.
+	ActivitySourceQuery   = "query"
+	ActivitySourceLogged  = "logged"
+	ActivitySourceDefault = "default"
+
+	FormulaMifflin = "mifflin_st_jeor"
+	FormulaKatch   = "katch_mcardle"
+)
+
+type EnergyEstimate struct {
+	Age             int      `json:"age"`
+	WeightKG        float64  `json:"weight"`
+	HeightCM        float64  `json:"height"`
+	BodyFat         *float64 `json:"body_fat"`
+	ActivityLevel   string   `json:"activity_level"`
+	ActivitySource  string   `json:"activity_source"`
+	ActivityFactor  float64  `json:"activity_factor"`
+	BMRMifflin      float64  `json:"bmr_mifflin_st_jeor"`
+	BMRKatch        *float64 `json:"bmr_katch_mcardle"`
+	BMR             float64  `json:"bmr"`
+	Formula         string   `json:"formula"`
+	TDEE            float64  `json:"tdee"`
+	CalorieTarget   float64  `json:"calorie_target"`
+	DailyAdjustment float64  `json:"daily_adjustment"`
+	GoalID          *int64   `json:"goal_id"`
+}
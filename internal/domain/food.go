@@ -0,0 +1,74 @@
+package domain
+
+import "time"
+
+const (
+	MealBreakfast = "breakfast"
+	MealLunch     = "lunch"
+	MealDinner    = "dinner"
+	MealSnack     = "snack"
+)
+
+var MealTypes = []string{MealBreakfast, MealLunch, MealDinner, MealSnack}
+
+// FoodEntry is one logged food or meal. Macronutrients are in grams and
+// optional, since many users only track calories.
+type FoodEntry struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Date      string    `json:"date"`
+	MealType  string    `json:"meal_type"`
+	Name      *string   `json:"name"`
+	Calories  float64   `json:"calories"`
+	Protein   *float64  `json:"protein"`
+	Carbs     *float64  `json:"carbs"`
+	Fat       *float64  `json:"fat"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FoodEntryRequest struct {
+	Date     string   `json:"date"`
+	MealType string   `json:"meal_type"`
+	Name     *string  `json:"name"`
+	Calories *float64 `json:"calories"`
+	Protein  *float64 `json:"protein"`
+	Carbs    *float64 `json:"carbs"`
+	Fat      *float64 `json:"fat"`
+}
+
+// DailyIntake totals the food log of one day, overall and per meal type.
+type DailyIntake struct {
+	Date     string             `json:"date"`
+	Entries  int                `json:"entries"`
+	Calories float64            `json:"calories"`
+	Protein  float64            `json:"protein"`
+	Carbs    float64            `json:"carbs"`
+	Fat      float64            `json:"fat"`
+	ByMeal   map[string]float64 `json:"calories_by_meal"`
+}
+
+// IntakeDay lines up a day's logged calories with the weight trend.
+type IntakeDay struct {
+	Date        string   `json:"date"`
+	Calories    *float64 `json:"calories"`
+	Weight      *float64 `json:"weight"`
+	TrendWeight *float64 `json:"trend_weight"`
+}
+
+// MaintenanceEstimate derives maintenance calories from logged intake and
+// the change in trend weight over the same period: intake minus the energy
+// stored or released by that change. Fields stay nil until there is enough
+// data.
+type MaintenanceEstimate struct {
+	WeightUnit          string      `json:"weight_unit"`
+	From                string      `json:"from"`
+	To                  string      `json:"to"`
+	LoggedDays          int         `json:"logged_days"`
+	AverageIntake       *float64    `json:"average_intake"`
+	TrendStart          *float64    `json:"trend_start"`
+	TrendEnd            *float64    `json:"trend_end"`
+	WeightChange        *float64    `json:"weight_change"`
+	WeeklyWeightChange  *float64    `json:"weekly_weight_change"`
+	MaintenanceCalories *float64    `json:"maintenance_calories"`
+	Days                []IntakeDay `json:"days"`
+}
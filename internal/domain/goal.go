@@ -0,0 +1,68 @@
+package domain
+
+import "time"
+
+const (
+	GoalTypeWeight = "weight"
+	GoalTypeBMI    = "bmi"
+
+	GoalStatusActive    = "active"
+	GoalStatusAchieved  = "achieved"
+	GoalStatusAbandoned = "abandoned"
+)
+
+type Goal struct {
+	ID          int64         `json:"id"`
+	UserID      int64         `json:"user_id"`
+	Type        string        `json:"type"`
+	TargetValue float64       `json:"target_value"`
+	StartValue  float64       `json:"start_value"`
+	StartWeight *float64      `json:"start_weight"`
+	StartBMI    *float64      `json:"start_bmi"`
+	StartDate   string        `json:"start_date"`
+	TargetDate  string        `json:"target_date"`
+	Status      string        `json:"status"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Progress    *GoalProgress `json:"progress,omitempty"`
+}
+
+type GoalRequest struct {
+	Type        string   `json:"type"`
+	TargetValue float64  `json:"target_value"`
+	TargetDate  string   `json:"target_date"`
+	StartValue  *float64 `json:"start_value"`
+}
+
+type GoalProgress struct {
+	CurrentValue       float64  `json:"current_value"`
+	PercentComplete    float64  `json:"percent_complete"`
+	RemainingDelta     float64  `json:"remaining_delta"`
+	DaysRemaining      int      `json:"days_remaining"`
+	RequiredWeeklyRate *float64 `json:"required_weekly_rate"`
+	RecentWeeklyRate   *float64 `json:"recent_weekly_rate"`
+	OnTrack            bool     `json:"on_track"`
+	Reached            bool     `json:"reached"`
+}
+
+const (
+	ForecastAchieved         = "achieved"
+	ForecastConverging       = "converging"
+	ForecastNotConverging    = "not_converging"
+	ForecastInsufficientData = "insufficient_data"
+)
+
+type GoalForecast struct {
+	GoalID        int64    `json:"goal_id"`
+	Method        string   `json:"method"`
+	Weeks         int      `json:"weeks"`
+	Samples       int      `json:"samples"`
+	Status        string   `json:"status"`
+	WeeklyRate    *float64 `json:"weekly_rate"`
+	ProjectedDate *string  `json:"projected_date"`
+	EarliestDate  *string  `json:"earliest_date"`
+	LatestDate    *string  `json:"latest_date"`
+	Confidence    float64  `json:"confidence"`
+	TargetDate    string   `json:"target_date"`
+	BeforeTarget  *bool    `json:"before_target"`
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+type IdempotencyKey struct {
+	ID           int64
+	AccountID    int64
+	Key          string
+	RequestHash  string
+	StatusCode   *int
+	ContentType  string
+	Location     string
+	ETag         string
+	ResponseBody []byte
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+const (
+	ImportRowImported  = "imported"
+	ImportRowDuplicate = "duplicate"
+	ImportRowInvalid   = "invalid"
+
+	ImportSourceCSV         = "csv"
+	ImportSourceAppleHealth = "apple_health"
+	ImportSourceGoogleFit   = "google_fit"
+	ImportSourceWithings    = "withings"
+	ImportSourceFHIR        = "fhir"
+
+	ImportJobQueued    = "queued"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+type ImportRow struct {
+	Row    int    `json:"row"`
+	Date   string `json:"date,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Total      int         `json:"total"`
+	Imported   int         `json:"imported"`
+	Duplicates int         `json:"duplicates"`
+	Invalid    int         `json:"invalid"`
+	Rows       []ImportRow `json:"rows"`
+}
+
+func (r *ImportReport) Add(row ImportRow) {
+	r.Total++
+	switch row.Status {
+	case ImportRowImported:
+		r.Imported++
+	case ImportRowDuplicate:
+		r.Duplicates++
+	case ImportRowInvalid:
+		r.Invalid++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+type ImportJob struct {
+	ID             int64         `json:"id"`
+	UserID         int64         `json:"user_id"`
+	Source         string        `json:"source"`
+	Status         string        `json:"status"`
+	BytesTotal     int64         `json:"bytes_total"`
+	BytesProcessed int64         `json:"bytes_processed"`
+	Progress       float64       `json:"progress"`
+	RecordsFound   int           `json:"records_found"`
+	Error          *string       `json:"error"`
+	Report         *ImportReport `json:"report"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,82 @@
+package domain
+
+import "time"
+
+const (
+	MeasurementBodyFat     = "body_fat"
+	MeasurementMuscleMass  = "muscle_mass"
+	MeasurementLeanMass    = "lean_mass"
+	MeasurementWaist       = "waist"
+	MeasurementHip         = "hip"
+	MeasurementNeck        = "neck"
+	MeasurementChest       = "chest"
+	MeasurementArm         = "arm"
+	MeasurementRestingHR   = "resting_heart_rate"
+	MeasurementSystolicBP  = "blood_pressure_systolic"
+	MeasurementDiastolicBP = "blood_pressure_diastolic"
+)
+
+type MeasurementKind struct {
+	Key       string  `json:"key"`
+	Unit      string  `json:"unit"`
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Precision int     `json:"precision"`
+}
+
+var measurementKinds = []MeasurementKind{
+	{Key: MeasurementBodyFat, Unit: "%", Min: 2, Max: 75, Precision: 1},
+	{Key: MeasurementMuscleMass, Unit: "kg", Min: 5, Max: 150, Precision: 1},
+	{Key: MeasurementLeanMass, Unit: "kg", Min: 10, Max: 200, Precision: 1},
+	{Key: MeasurementWaist, Unit: "cm", Min: 30, Max: 250, Precision: 1},
+	{Key: MeasurementHip, Unit: "cm", Min: 40, Max: 250, Precision: 1},
+	{Key: MeasurementNeck, Unit: "cm", Min: 15, Max: 80, Precision: 1},
+	{Key: MeasurementChest, Unit: "cm", Min: 40, Max: 250, Precision: 1},
+	{Key: MeasurementArm, Unit: "cm", Min: 10, Max: 80, Precision: 1},
+	{Key: MeasurementRestingHR, Unit: "bpm", Min: 25, Max: 220, Precision: 0},
+	{Key: MeasurementSystolicBP, Unit: "mmHg", Min: 60, Max: 260, Precision: 0},
+	{Key: MeasurementDiastolicBP, Unit: "mmHg", Min: 30, Max: 160, Precision: 0},
+}
+
+func MeasurementKinds() []MeasurementKind {
+	return append([]MeasurementKind(nil), measurementKinds...)
+}
+
+func LookupMeasurementKind(key string) (MeasurementKind, bool) {
+	for _, k := range measurementKinds {
+		if k.Key == key {
+			return k, true
+		}
+	}
+	return MeasurementKind{}, false
+}
+
+type Measurement struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Kind      string    `json:"kind"`
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit"`
+	Date      string    `json:"date"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MeasurementRequest struct {
+	Kind  string  `json:"kind"`
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+	Date  string  `json:"date"`
+}
+
+type BodyIndicator struct {
+	Key      string  `json:"key"`
+	Value    float64 `json:"value"`
+	Unit     string  `json:"unit"`
+	Category string  `json:"category"`
+	Source   string  `json:"source"`
+}
+
+type BodyIndicators struct {
+	Indicators []BodyIndicator `json:"indicators"`
+	Missing    []string        `json:"missing"`
+}
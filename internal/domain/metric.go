@@ -1,13 +1,80 @@
 package domain
 
+import "time"
+
+const (
+	BodyMetricUnderweight = "underweight"
+	BodyMetricNormal      = "normal"
+	BodyMetricOverweight  = "overweight"
+	BodyMetricObese       = "obese"
+
+	SuspectRateOfChange = "rate_of_change"
+	SuspectMAD          = "median_deviation"
+)
+
 type UserMetric struct {
-	ID         int64    `json:"id"`
-	UserID     int64    `json:"user_id"`
-	Date       string   `json:"date"`
-	Weight     *float64 `json:"weight"`
-	Height     int      `json:"height"`
-	BMI        float64  `json:"bmi"`
-	WeightDiff *float64 `json:"weight_diff"`
-	BodyMetric *string  `json:"body_metric"`
-	CreatedAt  *string  `json:"created_at"`
+	ID            int64        `json:"id"`
+	UUID          string       `json:"uuid"`
+	Version       int64        `json:"version"`
+	UserID        int64        `json:"user_id"`
+	Date          string       `json:"date"`
+	Weight        *float64     `json:"weight"`
+	Height        float64      `json:"height"`
+	HeightFtIn    *string      `json:"height_ft_in,omitempty"`
+	BMI           float64      `json:"bmi"`
+	WeightDiff    *float64     `json:"weight_diff"`
+	BodyMetric    *string      `json:"body_metric"`
+	CreatedAt     *string      `json:"created_at"`
+	Suspect       bool         `json:"suspect"`
+	SuspectReason *string      `json:"suspect_reason,omitempty"`
+	Confirmed     bool         `json:"confirmed"`
+	Note          *string      `json:"note"`
+	Tags          []string     `json:"tags,omitempty"`
+	BMIZScore     *float64     `json:"bmi_z_score,omitempty"`
+	BMIPercentile *float64     `json:"bmi_percentile,omitempty"`
+	WeightUnit    string       `json:"weight_unit,omitempty"`
+	HeightUnit    string       `json:"height_unit,omitempty"`
+	Warnings      []FieldError `json:"warnings,omitempty"`
+	ModifiedAt    time.Time    `json:"-"`
+}
+
+type BMIClassification struct {
+	Category   string
+	Pediatric  bool
+	ZScore     *float64
+	Percentile *float64
+}
+
+type TrendPoint struct {
+	Date          string  `json:"date"`
+	Weight        float64 `json:"weight"`
+	MovingAverage float64 `json:"moving_average"`
+	Trend         float64 `json:"trend"`
+	Samples       int     `json:"samples"`
+}
+
+type MetricTrend struct {
+	WeightUnit string       `json:"weight_unit"`
+	Window     int          `json:"window"`
+	Alpha      float64      `json:"alpha"`
+	Points     []TrendPoint `json:"points"`
+}
+
+// MetricSummary describes the weigh-ins in a period. Suspect entries are
+// counted but left out of every statistic.
+type MetricSummary struct {
+	WeightUnit       string   `json:"weight_unit"`
+	Days             int      `json:"days,omitempty"`
+	Count            int      `json:"count"`
+	SuspectCount     int      `json:"suspect_count"`
+	FirstDate        *string  `json:"first_date"`
+	LastDate         *string  `json:"last_date"`
+	StartWeight      *float64 `json:"start_weight"`
+	LatestWeight     *float64 `json:"latest_weight"`
+	MinWeight        *float64 `json:"min_weight"`
+	MaxWeight        *float64 `json:"max_weight"`
+	AverageWeight    *float64 `json:"average_weight"`
+	WeightChange     *float64 `json:"weight_change"`
+	LatestBMI        *float64 `json:"latest_bmi"`
+	LatestBodyMetric *string  `json:"latest_body_metric"`
 }
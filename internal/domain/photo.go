@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+type Photo struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"user_id"`
+	MetricID     *int64    `json:"metric_id"`
+	Date         string    `json:"date"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	SizeBytes    int64     `json:"size_bytes"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	CreatedAt    time.Time `json:"created_at"`
+	BlobKey      string    `json:"-"`
+	ThumbKey     string    `json:"-"`
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	SyncEntityProfile = "profile"
+	SyncEntityMetric  = "metric"
+
+	SyncOpUpsert = "upsert"
+	SyncOpDelete = "delete"
+
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncRejected = "rejected"
+)
+
+// SyncChange is one row change in either direction. Incoming changes carry
+// the version the client last saw in BaseVersion; outgoing ones carry the
+// server's current Version. Data holds the profile or metric in SI units and
+// is omitted for deletes (tombstones).
+type SyncChange struct {
+	Entity      string          `json:"entity"`
+	UUID        string          `json:"uuid"`
+	Op          string          `json:"op"`
+	BaseVersion int64           `json:"base_version,omitempty"`
+	Version     int64           `json:"version,omitempty"`
+	ModifiedAt  time.Time       `json:"modified_at"`
+	ProfileUUID string          `json:"profile_uuid,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	Seq         int64           `json:"-"`
+	Profile     *User           `json:"-"`
+	Metric      *UserMetric     `json:"-"`
+}
+
+type SyncRequest struct {
+	Checkpoint int64        `json:"checkpoint"`
+	Limit      int          `json:"limit"`
+	Changes    []SyncChange `json:"changes"`
+}
+
+// SyncResult reports what happened to one incoming change. On conflict the
+// server's winning row is returned in Current; a change rejected by
+// validation lists the offending fields in Errors.
+type SyncResult struct {
+	Entity  string       `json:"entity"`
+	UUID    string       `json:"uuid"`
+	Status  string       `json:"status"`
+	Version int64        `json:"version,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+	Current *SyncChange  `json:"current,omitempty"`
+}
+
+type SyncResponse struct {
+	Checkpoint int64        `json:"checkpoint"`
+	HasMore    bool         `json:"has_more"`
+	Results    []SyncResult `json:"results"`
+	Changes    []SyncChange `json:"changes"`
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	MaxTagsPerMetric = 10
+	MaxNoteLength    = 500
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Tag is an entry in a profile's tag vocabulary. Count is the number of
+// metrics currently carrying the tag.
+type Tag struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	Count     int       `json:"count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+// NormalizeTag trims and lower-cases a tag name and reports whether the
+// result is valid: 1-32 letters, digits, '-' or '_', not starting with a
+// separator.
+func NormalizeTag(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return name, tagPattern.MatchString(name)
+}
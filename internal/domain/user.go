@@ -1,15 +1,49 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// users.gender is stored as GenderMale or GenderFemale. The column existed
+// before the encoding was written down, so any other stored value is treated
+// as unknown and sex-specific calculations are skipped rather than guessed.
+const (
+	GenderMale   = 0
+	GenderFemale = 1
+)
+
+// KnownGender reports whether g holds one of the encoded genders.
+func KnownGender(g *int) bool {
+	return g != nil && (*g == GenderMale || *g == GenderFemale)
+}
 
 type User struct {
 	ID          int64     `json:"id"`
+	UUID        string    `json:"uuid"`
+	Version     int64     `json:"version"`
 	Name        *string   `json:"name"`
 	Surname     *string   `json:"surname"`
 	Gender      *int      `json:"gender"`
 	Avatar      *string   `json:"avatar"`
-	Height      *int      `json:"height"`
+	AvatarURL   *string   `json:"avatar_url"`
+	Height      *float64  `json:"height"`
+	HeightFtIn  *string   `json:"height_ft_in,omitempty"`
 	BirthOfDate *string   `json:"birthOfDate"`
+	WeightUnit  string    `json:"weight_unit"`
+	HeightUnit  string    `json:"height_unit"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	ModifiedAt  time.Time `json:"-"`
+	AvatarImage *string   `json:"-"`
+}
+
+// SetAvatarURL points AvatarURL at the uploaded avatar, or clears it when the
+// profile uses a preset.
+func (u *User) SetAvatarURL() {
+	u.AvatarURL = nil
+	if u.AvatarImage != nil {
+		url := fmt.Sprintf("/api/v1/users/%d/avatar", u.ID)
+		u.AvatarURL = &url
+	}
 }
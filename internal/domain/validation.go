@@ -0,0 +1,30 @@
+package domain
+
+const (
+	FieldUnknown      = "unknown_field"
+	FieldInvalid      = "invalid_type"
+	FieldRequired     = "required"
+	FieldTooShort     = "too_short"
+	FieldTooLong      = "too_long"
+	FieldOutOfRange   = "out_of_range"
+	FieldNotAllowed   = "not_allowed"
+	FieldInFuture     = "in_future"
+	FieldBadFormat    = "invalid_format"
+	FieldImplausible  = "implausible"
+	FieldInconsistent = "inconsistent"
+)
+
+// FieldError describes why one request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse lists every rejected field. ConfirmationRequired
+// is set when all problems are warnings the client may confirm.
+type ValidationErrorResponse struct {
+	Error                string       `json:"error"`
+	Fields               []FieldError `json:"fields"`
+	ConfirmationRequired bool         `json:"confirmation_required,omitempty"`
+}
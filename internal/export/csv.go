@@ -0,0 +1,37 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, weightUnit, heightUnit string) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(header(weightUnit, heightUnit)); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (c *csvWriter) Write(m domain.UserMetric) error {
+	return c.w.Write([]string{
+		m.Date,
+		formatFloat(m.Weight),
+		strconv.FormatFloat(m.Height, 'f', -1, 64),
+		strconv.FormatFloat(m.BMI, 'f', -1, 64),
+		formatFloat(m.WeightDiff),
+		stringOrEmpty(m.BodyMetric),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
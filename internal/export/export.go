@@ -0,0 +1,78 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	FormatXLSX = "xlsx"
+)
+
+// Writer encodes metrics one at a time so exports can be streamed straight
+// from the database. Close must be called to finish the document.
+type Writer interface {
+	Write(m domain.UserMetric) error
+	Close() error
+}
+
+// Format describes how an export is served over HTTP.
+type Format struct {
+	ContentType string
+	Extension   string
+}
+
+var formats = map[string]Format{
+	FormatCSV:  {ContentType: "text/csv; charset=utf-8", Extension: "csv"},
+	FormatJSON: {ContentType: "application/json", Extension: "json"},
+	FormatXLSX: {ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Extension: "xlsx"},
+}
+
+func LookupFormat(name string) (Format, bool) {
+	f, ok := formats[name]
+	return f, ok
+}
+
+// NewWriter returns a writer for format. Metrics must already be converted to
+// the units named in weightUnit and heightUnit, which label the columns.
+func NewWriter(format string, w io.Writer, weightUnit, heightUnit string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, weightUnit, heightUnit)
+	case FormatJSON:
+		return newJSONWriter(w)
+	case FormatXLSX:
+		return newXLSXWriter(w, weightUnit, heightUnit)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+func header(weightUnit, heightUnit string) []string {
+	return []string{
+		"date",
+		"weight_" + weightUnit,
+		"height_" + heightUnit,
+		"bmi",
+		"weight_diff_" + weightUnit,
+		"body_metric",
+	}
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func stringOrEmpty(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
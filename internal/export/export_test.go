@@ -0,0 +1,98 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func testMetrics() []domain.UserMetric {
+	weight, diff, normal := 72.4, -0.6, domain.BodyMetricNormal
+	return []domain.UserMetric{
+		{Date: "2024-04-01", Weight: &weight, Height: 180, BMI: 22.35, WeightDiff: &diff, BodyMetric: &normal},
+		{Date: "n/a & later", Height: 180},
+	}
+}
+
+func writeAll(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, "kg", "cm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range testMetrics() {
+		if err := w.Write(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSVWriter(t *testing.T) {
+	want := "date,weight_kg,height_cm,bmi,weight_diff_kg,body_metric\n" +
+		"2024-04-01,72.4,180,22.35,-0.6,normal\n" +
+		"n/a & later,,180,0,,\n"
+	if got := string(writeAll(t, FormatCSV)); got != want {
+		t.Errorf("csv =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	var got []domain.UserMetric
+	if err := json.Unmarshal(writeAll(t, FormatJSON), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Weight == nil || *got[0].Weight != 72.4 || got[1].Date != "n/a & later" {
+		t.Errorf("json = %+v", got)
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	data := writeAll(t, FormatXLSX)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip package: %v", err)
+	}
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		sheet = string(body)
+	}
+	if len(zr.File) != len(xlsxParts)+1 || sheet == "" {
+		t.Fatalf("package has %d parts, sheet found: %v", len(zr.File), sheet != "")
+	}
+	for _, want := range []string{
+		`<c r="B1" t="inlineStr" s="2"><is><t>weight_kg</t></is></c>`,
+		// 2024-04-01 is day 45383 counted from 1899-12-30.
+		`<c r="A2" s="1"><v>45383</v></c>`,
+		`<c r="B2"><v>72.4</v></c>`,
+		`<c r="A3" t="inlineStr"><is><t>n/a &amp; later</t></is></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet lacks %s", want)
+		}
+	}
+}
+
+func TestNewWriterRejectsUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("pdf", io.Discard, "kg", "cm"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,162 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+// The workbook is written by hand as a minimal SpreadsheetML package: one
+// sheet with inline strings and a date style, so rows can be streamed into the
+// zip without a shared string table.
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Metrics" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="3">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`</cellXfs>` +
+		`</styleSheet>`},
+}
+
+const (
+	xlsxStyleDate   = 1
+	xlsxStyleHeader = 2
+)
+
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+func newXLSXWriter(w io.Writer, weightUnit, heightUnit string) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`+
+		`<cols><col min="1" max="1" width="12" customWidth="1"/></cols><sheetData>`); err != nil {
+		return nil, err
+	}
+
+	x := &xlsxWriter{zw: zw, sheet: sheet}
+	var b strings.Builder
+	x.startRow(&b)
+	for i, h := range header(weightUnit, heightUnit) {
+		x.stringCell(&b, i, h, xlsxStyleHeader)
+	}
+	b.WriteString("</row>")
+	if _, err := io.WriteString(sheet, b.String()); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *xlsxWriter) Write(m domain.UserMetric) error {
+	var b strings.Builder
+	x.startRow(&b)
+	if day, err := calc.ParseDate(m.Date); err == nil {
+		// Excel serial dates count days from 1899-12-30.
+		serial := day.Sub(excelEpoch).Hours() / 24
+		x.numberCell(&b, 0, strconv.FormatFloat(serial, 'f', 0, 64), xlsxStyleDate)
+	} else {
+		x.stringCell(&b, 0, m.Date, 0)
+	}
+	if m.Weight != nil {
+		x.numberCell(&b, 1, formatFloat(m.Weight), 0)
+	}
+	x.numberCell(&b, 2, strconv.FormatFloat(m.Height, 'f', -1, 64), 0)
+	x.numberCell(&b, 3, strconv.FormatFloat(m.BMI, 'f', -1, 64), 0)
+	if m.WeightDiff != nil {
+		x.numberCell(&b, 4, formatFloat(m.WeightDiff), 0)
+	}
+	if m.BodyMetric != nil {
+		x.stringCell(&b, 5, *m.BodyMetric, 0)
+	}
+	b.WriteString("</row>")
+	_, err := io.WriteString(x.sheet, b.String())
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
+
+func (x *xlsxWriter) startRow(b *strings.Builder) {
+	x.row++
+	fmt.Fprintf(b, `<row r="%d">`, x.row)
+}
+
+func (x *xlsxWriter) ref(col int) string {
+	return string(rune('A'+col)) + strconv.Itoa(x.row)
+}
+
+func (x *xlsxWriter) numberCell(b *strings.Builder, col int, value string, style int) {
+	fmt.Fprintf(b, `<c r="%s"`, x.ref(col))
+	if style != 0 {
+		fmt.Fprintf(b, ` s="%d"`, style)
+	}
+	fmt.Fprintf(b, `><v>%s</v></c>`, value)
+}
+
+func (x *xlsxWriter) stringCell(b *strings.Builder, col int, value string, style int) {
+	fmt.Fprintf(b, `<c r="%s" t="inlineStr"`, x.ref(col))
+	if style != 0 {
+		fmt.Fprintf(b, ` s="%d"`, style)
+	}
+	b.WriteString(`><is><t>`)
+	xml.EscapeText(b, []byte(value))
+	b.WriteString(`</t></is></c>`)
+}
@@ -0,0 +1,190 @@
+package fhir
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/uuid"
+)
+
+const (
+	ContentType = "application/fhir+json"
+
+	SystemLOINC    = "http://loinc.org"
+	SystemUCUM     = "http://unitsofmeasure.org"
+	systemCategory = "http://terminology.hl7.org/CodeSystem/observation-category"
+
+	LOINCBodyWeight         = "29463-7"
+	LOINCBodyWeightMeasured = "3141-9"
+	LOINCBodyHeight         = "8302-2"
+	LOINCBMI                = "39156-5"
+)
+
+// Minimal FHIR R4 shapes: only the elements we read or write are modelled.
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Type         string        `json:"type"`
+	Timestamp    string        `json:"timestamp,omitempty"`
+	Total        *int          `json:"total,omitempty"`
+	Entry        []BundleEntry `json:"entry"`
+}
+
+type BundleEntry struct {
+	FullURL  string   `json:"fullUrl,omitempty"`
+	Resource Resource `json:"resource"`
+}
+
+// Resource is the union of the Patient and Observation elements we use,
+// discriminated by ResourceType.
+type Resource struct {
+	ResourceType      string            `json:"resourceType"`
+	ID                string            `json:"id,omitempty"`
+	Name              []HumanName       `json:"name,omitempty"`
+	Gender            string            `json:"gender,omitempty"`
+	BirthDate         string            `json:"birthDate,omitempty"`
+	Status            string            `json:"status,omitempty"`
+	Category          []CodeableConcept `json:"category,omitempty"`
+	Code              *CodeableConcept  `json:"code,omitempty"`
+	Subject           *Reference        `json:"subject,omitempty"`
+	EffectiveDateTime string            `json:"effectiveDateTime,omitempty"`
+	EffectivePeriod   *Period           `json:"effectivePeriod,omitempty"`
+	Issued            string            `json:"issued,omitempty"`
+	ValueQuantity     *Quantity         `json:"valueQuantity,omitempty"`
+}
+
+type HumanName struct {
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type Quantity struct {
+	Value  *float64 `json:"value"`
+	Unit   string   `json:"unit,omitempty"`
+	System string   `json:"system,omitempty"`
+	Code   string   `json:"code,omitempty"`
+}
+
+// HasCode reports whether the concept carries the given LOINC code.
+func (c *CodeableConcept) HasCode(code string) bool {
+	if c == nil {
+		return false
+	}
+	for _, coding := range c.Coding {
+		if coding.System == SystemLOINC && coding.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveTime returns the clinically relevant time of an observation.
+func (r Resource) EffectiveTime() string {
+	switch {
+	case r.EffectiveDateTime != "":
+		return r.EffectiveDateTime
+	case r.EffectivePeriod != nil && r.EffectivePeriod.Start != "":
+		return r.EffectivePeriod.Start
+	}
+	return r.Issued
+}
+
+// BuildBundle exports a profile and its metrics as a collection Bundle with
+// one Patient and weight, height and BMI Observations. Entries are linked by
+// urn:uuid full URLs so the Bundle stands alone outside this server.
+func BuildBundle(user *domain.User, metrics []domain.UserMetric, now time.Time) Bundle {
+	patientURL := "urn:uuid:" + uuid.New()
+	bundle := Bundle{
+		ResourceType: "Bundle",
+		Type:         "collection",
+		Timestamp:    now.UTC().Format(time.RFC3339),
+		Entry:        []BundleEntry{{FullURL: patientURL, Resource: patient(user)}},
+	}
+	subject := &Reference{Reference: patientURL}
+
+	for _, m := range metrics {
+		date := m.Date
+		if day, err := calc.ParseDate(m.Date); err == nil {
+			date = day.Format(calc.DateLayout)
+		}
+		add := func(prefix, code, display string, value float64, unit string) {
+			v := value
+			bundle.Entry = append(bundle.Entry, BundleEntry{
+				FullURL: "urn:uuid:" + uuid.New(),
+				Resource: Resource{
+					ResourceType: "Observation",
+					ID:           prefix + "-" + strconv.FormatInt(m.ID, 10),
+					Status:       "final",
+					Category: []CodeableConcept{{Coding: []Coding{{
+						System: systemCategory, Code: "vital-signs", Display: "Vital Signs",
+					}}}},
+					Code: &CodeableConcept{
+						Coding: []Coding{{System: SystemLOINC, Code: code, Display: display}},
+						Text:   display,
+					},
+					Subject:           subject,
+					EffectiveDateTime: date,
+					ValueQuantity:     &Quantity{Value: &v, Unit: unit, System: SystemUCUM, Code: unit},
+				},
+			})
+		}
+		if m.Weight != nil {
+			add("weight", LOINCBodyWeight, "Body weight", *m.Weight, "kg")
+		}
+		if m.Height > 0 {
+			add("height", LOINCBodyHeight, "Body height", m.Height, "cm")
+		}
+		if m.BMI > 0 {
+			add("bmi", LOINCBMI, "Body mass index (BMI) [Ratio]", calc.Round(m.BMI, 2), "kg/m2")
+		}
+	}
+	return bundle
+}
+
+func patient(user *domain.User) Resource {
+	p := Resource{ResourceType: "Patient", ID: strconv.FormatInt(user.ID, 10)}
+	var name HumanName
+	if user.Surname != nil {
+		name.Family = *user.Surname
+	}
+	if user.Name != nil {
+		name.Given = []string{*user.Name}
+	}
+	if name.Family != "" || len(name.Given) > 0 {
+		p.Name = []HumanName{name}
+	}
+	if domain.KnownGender(user.Gender) {
+		p.Gender = "male"
+		if *user.Gender == domain.GenderFemale {
+			p.Gender = "female"
+		}
+	}
+	if user.BirthOfDate != nil {
+		if birth, err := calc.ParseDate(*user.BirthOfDate); err == nil {
+			p.BirthDate = birth.Format(calc.DateLayout)
+		}
+	}
+	return p
+}
@@ -0,0 +1,315 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+)
+
+const (
+	maxDailySteps     = 100000
+	maxWorkoutMinutes = 24 * 60
+	minWorkoutMET     = 1
+	maxWorkoutMET     = 23
+	maxActivityDays   = 366
+	maxActivityWeeks  = 52
+
+	// activityPeriodDays is the window of logged activity the energy
+	// estimate derives its activity level from.
+	activityPeriodDays = 28
+)
+
+type ActivityHandler struct {
+	repo       *repository.ActivityRepository
+	metricRepo *repository.MetricRepository
+	userRepo   *repository.UserRepository
+}
+
+func NewActivityHandler(
+	repo *repository.ActivityRepository,
+	metricRepo *repository.MetricRepository,
+	userRepo *repository.UserRepository,
+) *ActivityHandler {
+	return &ActivityHandler{repo: repo, metricRepo: metricRepo, userRepo: userRepo}
+}
+
+func (h *ActivityHandler) WorkoutTypes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, domain.WorkoutTypes())
+}
+
+// SaveSteps records the step count of a day (default today), replacing any
+// earlier count for that day.
+func (h *ActivityHandler) SaveSteps(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	var req domain.StepCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var errs []domain.FieldError
+	date, dateErr := entryDate(req.Date, time.Now().UTC())
+	if dateErr != nil {
+		errs = append(errs, *dateErr)
+	}
+	switch {
+	case req.Steps == nil:
+		errs = append(errs, domain.FieldError{Field: "steps", Code: domain.FieldRequired, Message: "steps is required"})
+	case *req.Steps < 0 || *req.Steps > maxDailySteps:
+		errs = append(errs, domain.FieldError{
+			Field:   "steps",
+			Code:    domain.FieldOutOfRange,
+			Message: fmt.Sprintf("steps must be between 0 and %d", maxDailySteps),
+		})
+	}
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	saved, err := h.repo.SaveSteps(&domain.StepCount{UserID: user.ID, Date: date.Format(calc.DateLayout), Steps: *req.Steps})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save steps")
+		return
+	}
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// GetSteps lists the step counts of the last `days` days (7) or from/to.
+func (h *ActivityHandler) GetSteps(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+	from, to, ok := queryDateRange(w, r, 7, maxActivityDays)
+	if !ok {
+		return
+	}
+
+	counts, err := h.repo.GetStepsBetween(user.ID, from.Format(calc.DateLayout), to.Format(calc.DateLayout))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list steps")
+		return
+	}
+	if counts == nil {
+		counts = []domain.StepCount{}
+	}
+
+	writeJSONWithETag(w, r, http.StatusOK, "", counts)
+}
+
+func (h *ActivityHandler) DeleteSteps(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	date, err := calc.ParseDate(mux.Vars(r)["date"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid date")
+		return
+	}
+
+	deleted, err := h.repo.DeleteSteps(user.ID, date.Format(calc.DateLayout))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete steps")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "steps not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateWorkout logs a workout and estimates its calories from the type's
+// MET value (or the given met), the duration and the latest weigh-in.
+func (h *ActivityHandler) CreateWorkout(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	var req domain.WorkoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	workout, errs := validateWorkout(req, time.Now().UTC())
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	weights := calc.DailyWeights(calc.WithoutSuspects(metrics))
+	if len(weights) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "at least one weight metric is required")
+		return
+	}
+
+	workout.UserID = user.ID
+	workout.WeightKG = weights[len(weights)-1].Value
+	workout.Calories = calc.WorkoutCalories(workout.MET, workout.WeightKG, workout.DurationMin)
+
+	id, err := h.repo.CreateWorkout(&workout)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create workout")
+		return
+	}
+
+	created, err := h.repo.GetWorkoutByIDAndUserID(id, user.ID)
+	if err != nil || created == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get created workout")
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// GetWorkouts lists the workouts of the last `days` days (7) or from/to.
+func (h *ActivityHandler) GetWorkouts(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+	from, to, ok := queryDateRange(w, r, 7, maxActivityDays)
+	if !ok {
+		return
+	}
+
+	workouts, err := h.repo.GetWorkoutsBetween(user.ID, from.Format(calc.DateLayout), to.Format(calc.DateLayout))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list workouts")
+		return
+	}
+	if workouts == nil {
+		workouts = []domain.Workout{}
+	}
+
+	writeJSONWithETag(w, r, http.StatusOK, "", workouts)
+}
+
+func (h *ActivityHandler) DeleteWorkout(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	workoutID, err := strconv.ParseInt(mux.Vars(r)["workoutId"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid workout id")
+		return
+	}
+
+	deleted, err := h.repo.DeleteWorkoutByIDAndUserID(workoutID, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete workout")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "workout not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Weekly summarises the last `weeks` weeks (4), the current week included.
+func (h *ActivityHandler) Weekly(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+	weeks, ok := queryInt(r, "weeks", 4, 1, maxActivityWeeks)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("weeks must be between 1 and %d", maxActivityWeeks))
+		return
+	}
+
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := calc.WeekStart(to).AddDate(0, 0, -7*(weeks-1))
+	steps, workouts, ok := loadActivity(w, h.repo, user.ID, from, to)
+	if !ok {
+		return
+	}
+
+	writeJSONWithETag(w, r, http.StatusOK, "", calc.WeeklyActivity(steps, workouts, from, to))
+}
+
+// loadActivity reads the steps and workouts of from..to, writing the error
+// response when either fails.
+func loadActivity(w http.ResponseWriter, repo *repository.ActivityRepository, userID int64, from, to time.Time) ([]domain.StepCount, []domain.Workout, bool) {
+	steps, err := repo.GetStepsBetween(userID, from.Format(calc.DateLayout), to.Format(calc.DateLayout))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list steps")
+		return nil, nil, false
+	}
+	workouts, err := repo.GetWorkoutsBetween(userID, from.Format(calc.DateLayout), to.Format(calc.DateLayout))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list workouts")
+		return nil, nil, false
+	}
+	return steps, workouts, true
+}
+
+// validateWorkout checks a workout submission and returns the workout to
+// store without its weight and calories.
+func validateWorkout(req domain.WorkoutRequest, now time.Time) (domain.Workout, []domain.FieldError) {
+	var errs []domain.FieldError
+	fail := func(field, code, format string, args ...interface{}) {
+		errs = append(errs, domain.FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var workout domain.Workout
+	if date, dateErr := entryDate(req.Date, now); dateErr != nil {
+		errs = append(errs, *dateErr)
+	} else {
+		workout.Date = date.Format(calc.DateLayout)
+	}
+
+	kind, ok := domain.LookupWorkoutType(req.Type)
+	switch {
+	case req.Type == "":
+		fail("type", domain.FieldRequired, "type is required")
+	case !ok:
+		fail("type", domain.FieldNotAllowed, "unknown workout type %q", req.Type)
+	default:
+		workout.Type, workout.MET = kind.Key, kind.MET
+	}
+
+	switch {
+	case req.DurationMin == nil:
+		fail("duration_min", domain.FieldRequired, "duration_min is required")
+	case *req.DurationMin < 1 || *req.DurationMin > maxWorkoutMinutes:
+		fail("duration_min", domain.FieldOutOfRange, "duration_min must be between 1 and %d", maxWorkoutMinutes)
+	default:
+		workout.DurationMin = *req.DurationMin
+	}
+
+	if req.MET != nil {
+		if math.IsNaN(*req.MET) || *req.MET < minWorkoutMET || *req.MET > maxWorkoutMET {
+			fail("met", domain.FieldOutOfRange, "met must be between %d and %d", minWorkoutMET, maxWorkoutMET)
+		} else {
+			workout.MET = calc.Round(*req.MET, 1)
+		}
+	}
+	return workout, errs
+}
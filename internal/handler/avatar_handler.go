@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/imaging"
+	"github.com/yusufkecer/body-metrics-backend/internal/middleware"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+	"github.com/yusufkecer/body-metrics-backend/internal/storage"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+	"github.com/yusufkecer/body-metrics-backend/internal/uuid"
+)
+
+// MaxAvatarUpload is the body limit for avatar uploads; main.go registers it
+// as an override of the global limit.
+const MaxAvatarUpload = 5 << 20
+
+const defaultAvatarSize = 256
+
+// avatarSizes are the square renditions stored for every uploaded avatar.
+var avatarSizes = []int{64, 128, 256, 512}
+
+type AvatarHandler struct {
+	userRepo       *repository.UserRepository
+	store          storage.Store
+	requireIfMatch bool
+}
+
+func NewAvatarHandler(userRepo *repository.UserRepository, store storage.Store, requireIfMatch bool) *AvatarHandler {
+	return &AvatarHandler{userRepo: userRepo, store: store, requireIfMatch: requireIfMatch}
+}
+
+// Put replaces the profile's avatar with an uploaded JPEG or PNG, sent as the
+// raw body or as the "file" field of a multipart form. The image is cropped
+// to a square and stored in every size of avatarSizes.
+func (h *AvatarHandler) Put(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+	if !h.checkIfMatch(w, r, user) {
+		return
+	}
+
+	body, err := uploadedFile(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("avatar must not exceed %d MB", MaxAvatarUpload>>20))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "failed to read avatar")
+		return
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		writeError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+
+	imageID := uuid.New()
+	for _, size := range avatarSizes {
+		var buf bytes.Buffer
+		if err := imaging.EncodeJPEG(&buf, imaging.Square(img, size), photoQuality); err != nil {
+			h.deleteBlobs(user.ID, imageID)
+			writeError(w, http.StatusInternalServerError, "failed to encode avatar")
+			return
+		}
+		if err := h.store.Put(r.Context(), avatarKey(user.ID, imageID, size), &buf); err != nil {
+			h.deleteBlobs(user.ID, imageID)
+			writeError(w, http.StatusInternalServerError, "failed to store avatar")
+			return
+		}
+	}
+
+	if !h.setImage(w, r, user, &imageID) {
+		h.deleteBlobs(user.ID, imageID)
+		return
+	}
+	if user.AvatarImage != nil {
+		h.deleteBlobs(user.ID, *user.AvatarImage)
+	}
+	h.writeUser(w, r, user.ID)
+}
+
+// Get serves the uploaded avatar at `size` pixels (default 256). The URL
+// stays the same across uploads, so responses are revalidated by ETag.
+func (h *AvatarHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+	if user.AvatarImage == nil {
+		writeError(w, http.StatusNotFound, "avatar not found")
+		return
+	}
+
+	size := defaultAvatarSize
+	if v := r.URL.Query().Get("size"); v != "" {
+		size, _ = strconv.Atoi(v)
+		if !validAvatarSize(size) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("size must be one of %v", avatarSizes))
+			return
+		}
+	}
+
+	etag := fmt.Sprintf(`"%s-%d"`, *user.AvatarImage, size)
+	w.Header().Set("ETag", etag)
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, etag, true) {
+		w.Header().Set("Cache-Control", "private, max-age=3600")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	serveBlob(w, r, h.store, avatarKey(user.ID, *user.AvatarImage, size), "private, max-age=3600")
+}
+
+// Delete removes the uploaded avatar so the profile falls back to its preset
+// avatar identifier.
+func (h *AvatarHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+	if !h.checkIfMatch(w, r, user) {
+		return
+	}
+	if user.AvatarImage == nil {
+		writeError(w, http.StatusNotFound, "avatar not found")
+		return
+	}
+
+	if !h.setImage(w, r, user, nil) {
+		return
+	}
+	h.deleteBlobs(user.ID, *user.AvatarImage)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AvatarHandler) setImage(w http.ResponseWriter, r *http.Request, user *domain.User, imageID *string) bool {
+	var version int64
+	if r.Header.Get("If-Match") != "" {
+		version = user.Version
+	}
+	accountID, _ := r.Context().Value(middleware.AccountIDKey).(int64)
+	err := h.userRepo.UpdateByIDAndAccountID(user.ID, accountID, version, map[string]interface{}{"avatar_image": imageID})
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		writeError(w, http.StatusPreconditionFailed, "resource has been modified")
+		return false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update avatar")
+		return false
+	}
+	return true
+}
+
+func (h *AvatarHandler) writeUser(w http.ResponseWriter, r *http.Request, userID int64) {
+	accountID, _ := r.Context().Value(middleware.AccountIDKey).(int64)
+	user, err := h.userRepo.GetByIDAndAccountID(userID, accountID)
+	if err != nil || user == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get updated user")
+		return
+	}
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.Header().Set("ETag", userETag(*user, pref))
+	units.UserFromSI(user, pref)
+	writeJSON(w, http.StatusOK, user)
+}
+
+// checkIfMatch compares If-Match with the profile's ETag in the request's
+// units, as GET /users/{id} serves it.
+func (h *AvatarHandler) checkIfMatch(w http.ResponseWriter, r *http.Request, user *domain.User) bool {
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return checkIfMatch(w, r, userETag(*user, pref), h.requireIfMatch)
+}
+
+// deleteBlobs removes every size of an avatar on a best-effort basis.
+func (h *AvatarHandler) deleteBlobs(userID int64, imageID string) {
+	for _, size := range avatarSizes {
+		key := avatarKey(userID, imageID, size)
+		if err := h.store.Delete(context.Background(), key); err != nil {
+			log.Printf("[avatar] failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+func avatarKey(userID int64, imageID string, size int) string {
+	return fmt.Sprintf("avatars/%d/%s_%d.jpg", userID, imageID, size)
+}
+
+func validAvatarSize(size int) bool {
+	for _, s := range avatarSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+)
+
+type EnergyHandler struct {
+	userRepo        *repository.UserRepository
+	metricRepo      *repository.MetricRepository
+	measurementRepo *repository.MeasurementRepository
+	goalRepo        *repository.GoalRepository
+	activityRepo    *repository.ActivityRepository
+}
+
+func NewEnergyHandler(
+	userRepo *repository.UserRepository,
+	metricRepo *repository.MetricRepository,
+	measurementRepo *repository.MeasurementRepository,
+	goalRepo *repository.GoalRepository,
+	activityRepo *repository.ActivityRepository,
+) *EnergyHandler {
+	return &EnergyHandler{
+		userRepo:        userRepo,
+		metricRepo:      metricRepo,
+		measurementRepo: measurementRepo,
+		goalRepo:        goalRepo,
+		activityRepo:    activityRepo,
+	}
+}
+
+func (h *EnergyHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	activity, source := r.URL.Query().Get("activity"), domain.ActivitySourceQuery
+	if _, ok := calc.ActivityFactors[activity]; activity != "" && !ok {
+		writeError(w, http.StatusBadRequest, "activity must be one of sedentary, light, moderate, active, very_active")
+		return
+	}
+
+	if !domain.KnownGender(user.Gender) || user.Height == nil || user.BirthOfDate == nil {
+		writeError(w, http.StatusUnprocessableEntity, "profile gender, height and birth date are required")
+		return
+	}
+	birth, err := calc.ParseDate(*user.BirthOfDate)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "profile birth date is invalid")
+		return
+	}
+
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	metrics = calc.WithoutSuspects(metrics)
+	weights := calc.DailyWeights(metrics)
+	if len(weights) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "at least one weight metric is required")
+		return
+	}
+
+	latest, err := h.measurementRepo.GetLatestByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list measurements")
+		return
+	}
+
+	now := time.Now().UTC()
+	if activity == "" {
+		// Without an explicit level, use the one the logged steps and
+		// workouts of the last activityPeriodDays days point to.
+		to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		steps, workouts, ok := loadActivity(w, h.activityRepo, user.ID, to.AddDate(0, 0, 1-activityPeriodDays), to)
+		if !ok {
+			return
+		}
+		activity, source = domain.ActivitySedentary, domain.ActivitySourceDefault
+		if level, ok := calc.LoggedActivityLevel(steps, workouts, activityPeriodDays); ok {
+			activity, source = level, domain.ActivitySourceLogged
+		}
+	}
+
+	in := calc.EnergyInput{
+		Gender:        *user.Gender,
+		WeightKG:      weights[len(weights)-1].Value,
+		HeightCM:      *user.Height,
+		Age:           calc.AgeYears(birth, now),
+		ActivityLevel: activity,
+	}
+	if bf, ok := latest[domain.MeasurementBodyFat]; ok {
+		in.BodyFat = &bf.Value
+	}
+
+	goal, err := h.goalRepo.GetActiveByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get active goal")
+		return
+	}
+	if goal != nil {
+		progress := calc.GoalProgress(*goal, calc.GoalSeries(goal.Type, metrics), now)
+		if rate := progress.RequiredWeeklyRate; rate != nil && !progress.Reached {
+			weekly := *rate
+			if goal.Type == domain.GoalTypeBMI {
+				meters := in.HeightCM / 100
+				weekly *= meters * meters
+			}
+			in.WeeklyWeightChange = &weekly
+		}
+	}
+
+	estimate := calc.Energy(in)
+	estimate.ActivitySource = source
+	if goal != nil {
+		estimate.GoalID = &goal.ID
+	}
+	writeJSON(w, http.StatusOK, estimate)
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+)
+
+// representationETag is the strong ETag of a versioned resource as served.
+// The version alone does not identify the body: the unit parameters change
+// it, and a metric's derived fields are recomputed without a new version. The
+// version stays in front so tags remain readable.
+func representationETag(version int64, served interface{}) string {
+	tag := strconv.FormatInt(version, 10)
+	if body, err := json.Marshal(served); err == nil {
+		sum := sha256.Sum256(body)
+		tag += "-" + hex.EncodeToString(sum[:8])
+	}
+	return `"` + tag + `"`
+}
+
+// metricETag is the ETag of m as GET serves it in pref's units.
+func metricETag(m domain.UserMetric, user *domain.User, pref units.Preference) string {
+	calc.AnnotateBMIForAge(&m, user)
+	units.MetricFromSI(&m, pref)
+	m.Warnings = nil
+	return representationETag(m.Version, m)
+}
+
+// userETag is the ETag of u as GET serves it in pref's units.
+func userETag(u domain.User, pref units.Preference) string {
+	units.UserFromSI(&u, pref)
+	return representationETag(u.Version, u)
+}
+
+// etagMatches reports whether a conditional header lists etag. The weak
+// comparison used by If-None-Match treats W/"x" and "x" as equal; the strong
+// comparison used by If-Match (RFC 9110 section 8.8.3.2) never matches a weak
+// validator on either side.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		switch {
+		case candidate == "*":
+			return true
+		case weak:
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		case !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag:
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces If-Match for a write against the resource's current
+// ETag. A missing header is accepted unless required is set.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, etag string, required bool) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if required {
+			writeError(w, http.StatusPreconditionRequired, "If-Match header is required")
+			return false
+		}
+		return true
+	}
+	if !etagMatches(header, etag, false) {
+		w.Header().Set("ETag", etag)
+		writeError(w, http.StatusPreconditionFailed, "resource has been modified")
+		return false
+	}
+	return true
+}
+
+// writeJSONWithETag writes data with an ETag, or 304 when it matches
+// If-None-Match. An empty etag is derived from the encoded body.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, status int, etag string, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	}
+
+	w.Header().Set("ETag", etag)
+	if header := r.Header.Get("If-None-Match"); header != "" && etagMatches(header, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(body, '\n'))
+}
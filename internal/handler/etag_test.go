@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header, etag string
+		weak, want   bool
+	}{
+		{`"3"`, `"3"`, false, true},
+		{`"2", "3"`, `"3"`, false, true},
+		{`*`, `"3"`, false, true},
+		{`"4"`, `"3"`, false, false},
+		{`W/"3"`, `"3"`, false, false},
+		{`"3"`, `W/"3"`, false, false},
+		{`W/"3"`, `W/"3"`, false, false},
+		{`W/"3"`, `"3"`, true, true},
+		{`"3"`, `W/"3"`, true, true},
+		{`W/"a", W/"b"`, `W/"b"`, true, true},
+		{`W/"4"`, `"3"`, true, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, tt.etag, tt.weak); got != tt.want {
+			t.Errorf("etagMatches(%s, %s, weak=%v) = %v, want %v", tt.header, tt.etag, tt.weak, got, tt.want)
+		}
+	}
+}
+
+func TestMetricETag(t *testing.T) {
+	weight, diff := 80.0, -0.5
+	user := &domain.User{ID: 7}
+	metric := domain.UserMetric{ID: 3, Version: 2, Date: "2024-05-01", Weight: &weight, Height: 180, BMI: 24.69, WeightDiff: &diff}
+	metric.Warnings = []domain.FieldError{{Field: "weight", Code: domain.FieldImplausible}}
+	base := metricETag(metric, user, units.Default)
+
+	if !strings.HasPrefix(base, `"2-`) {
+		t.Errorf("etag = %s, want the version in front", base)
+	}
+	if metric.Warnings == nil || *metric.Weight != 80 {
+		t.Error("metricETag changed its argument")
+	}
+	withoutWarnings := metric
+	withoutWarnings.Warnings = nil
+	if got := metricETag(withoutWarnings, user, units.Default); got != base {
+		t.Errorf("warnings changed the etag: %s vs %s", got, base)
+	}
+
+	recomputed := metric
+	newDiff := -0.7
+	recomputed.WeightDiff = &newDiff
+	if got := metricETag(recomputed, user, units.Default); got == base {
+		t.Error("a recomputed weight_diff kept the etag")
+	}
+	if got := metricETag(metric, user, units.Preference{Weight: units.Pound, Height: units.Inch}); got == base {
+		t.Error("other units kept the etag")
+	}
+}
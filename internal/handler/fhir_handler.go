@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/fhir"
+	"github.com/yusufkecer/body-metrics-backend/internal/importer"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+	"github.com/yusufkecer/body-metrics-backend/internal/service"
+)
+
+type FHIRHandler struct {
+	importService *service.ImportService
+	metricRepo    *repository.MetricRepository
+	userRepo      *repository.UserRepository
+}
+
+func NewFHIRHandler(
+	importService *service.ImportService,
+	metricRepo *repository.MetricRepository,
+	userRepo *repository.UserRepository,
+) *FHIRHandler {
+	return &FHIRHandler{importService: importService, metricRepo: metricRepo, userRepo: userRepo}
+}
+
+func (h *FHIRHandler) Export(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+
+	w.Header().Set("Content-Type", fhir.ContentType)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fhir.BuildBundle(user, metrics, time.Now().UTC()))
+}
+
+func (h *FHIRHandler) Import(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	records, invalid, err := importer.FHIR{}.Parse(r.Body, time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.importService.Save(user, records, invalid)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to import metrics")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
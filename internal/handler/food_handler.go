@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+)
+
+const (
+	maxEntryCalories   = 10000
+	maxEntryMacroGrams = 1000
+	maxFoodNameLength  = 100
+	maxFoodLogDays     = 366
+)
+
+type FoodHandler struct {
+	repo       *repository.FoodRepository
+	metricRepo *repository.MetricRepository
+	userRepo   *repository.UserRepository
+}
+
+func NewFoodHandler(
+	repo *repository.FoodRepository,
+	metricRepo *repository.MetricRepository,
+	userRepo *repository.UserRepository,
+) *FoodHandler {
+	return &FoodHandler{repo: repo, metricRepo: metricRepo, userRepo: userRepo}
+}
+
+func (h *FoodHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	var req domain.FoodEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	entry, errs := validateFoodEntry(req, time.Now().UTC())
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return
+	}
+	entry.UserID = user.ID
+
+	id, err := h.repo.Create(&entry)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create food entry")
+		return
+	}
+
+	created, err := h.repo.GetByIDAndUserID(id, user.ID)
+	if err != nil || created == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get created food entry")
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// GetByUserID lists the entries of the last `days` days (7) or from/to.
+func (h *FoodHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+	from, to, ok := queryDateRange(w, r, 7, maxFoodLogDays)
+	if !ok {
+		return
+	}
+
+	entries, err := h.repo.GetByUserIDBetween(user.ID, from.Format(calc.DateLayout), to.Format(calc.DateLayout))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list food entries")
+		return
+	}
+	if entries == nil {
+		entries = []domain.FoodEntry{}
+	}
+
+	writeJSONWithETag(w, r, http.StatusOK, "", entries)
+}
+
+// Daily totals calories and macronutrients per logged day.
+func (h *FoodHandler) Daily(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+	from, to, ok := queryDateRange(w, r, 7, maxFoodLogDays)
+	if !ok {
+		return
+	}
+
+	entries, err := h.repo.GetByUserIDBetween(user.ID, from.Format(calc.DateLayout), to.Format(calc.DateLayout))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list food entries")
+		return
+	}
+	days := calc.DailyIntakes(entries)
+	if days == nil {
+		days = []domain.DailyIntake{}
+	}
+
+	writeJSONWithETag(w, r, http.StatusOK, "", days)
+}
+
+// Maintenance compares logged intake with the weight trend over the last
+// `days` days (28) or from/to to estimate the user's real maintenance
+// calories. alpha sets the trend smoothing as in /metrics/trend.
+func (h *FoodHandler) Maintenance(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+	from, to, ok := queryDateRange(w, r, 28, maxFoodLogDays)
+	if !ok {
+		return
+	}
+	alpha, ok := queryFloat(r, "alpha", 0.1, 0.01, 1)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "alpha must be between 0.01 and 1")
+		return
+	}
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := h.repo.GetByUserIDBetween(user.ID, from.Format(calc.DateLayout), to.Format(calc.DateLayout))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list food entries")
+		return
+	}
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	weights := calc.DailyWeights(calc.WithoutSuspects(metrics))
+
+	estimate := calc.Maintenance(calc.DailyIntakes(entries), weights, from, to, alpha)
+	units.MaintenanceFromSI(&estimate, pref.Weight)
+	writeJSON(w, http.StatusOK, estimate)
+}
+
+func (h *FoodHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	entryID, err := strconv.ParseInt(mux.Vars(r)["entryId"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid food entry id")
+		return
+	}
+
+	deleted, err := h.repo.DeleteByIDAndUserID(entryID, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete food entry")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "food entry not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateFoodEntry checks a food log submission and returns the entry to
+// store, dated today when the request has no date.
+func validateFoodEntry(req domain.FoodEntryRequest, now time.Time) (domain.FoodEntry, []domain.FieldError) {
+	var errs []domain.FieldError
+	fail := func(field, code, format string, args ...interface{}) {
+		errs = append(errs, domain.FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	entry := domain.FoodEntry{MealType: req.MealType, Protein: req.Protein, Carbs: req.Carbs, Fat: req.Fat}
+
+	if date, dateErr := entryDate(req.Date, now); dateErr != nil {
+		errs = append(errs, *dateErr)
+	} else {
+		entry.Date = date.Format(calc.DateLayout)
+	}
+
+	switch {
+	case req.MealType == "":
+		fail("meal_type", domain.FieldRequired, "meal_type is required")
+	case !validMealType(req.MealType):
+		fail("meal_type", domain.FieldNotAllowed, "meal_type must be one of %s", strings.Join(domain.MealTypes, ", "))
+	}
+
+	if req.Name != nil {
+		if name := strings.TrimSpace(*req.Name); name == "" {
+			entry.Name = nil
+		} else if utf8.RuneCountInString(name) > maxFoodNameLength {
+			fail("name", domain.FieldTooLong, "name must be at most %d characters", maxFoodNameLength)
+		} else {
+			entry.Name = &name
+		}
+	}
+
+	switch {
+	case req.Calories == nil:
+		fail("calories", domain.FieldRequired, "calories is required")
+	case math.IsNaN(*req.Calories) || *req.Calories < 0 || *req.Calories > maxEntryCalories:
+		fail("calories", domain.FieldOutOfRange, "calories must be between 0 and %d", maxEntryCalories)
+	default:
+		entry.Calories = calc.Round(*req.Calories, 0)
+	}
+
+	for _, macro := range []struct {
+		field string
+		value **float64
+	}{{"protein", &entry.Protein}, {"carbs", &entry.Carbs}, {"fat", &entry.Fat}} {
+		v := *macro.value
+		if v == nil {
+			continue
+		}
+		if math.IsNaN(*v) || *v < 0 || *v > maxEntryMacroGrams {
+			fail(macro.field, domain.FieldOutOfRange, "%s must be between 0 and %d g", macro.field, maxEntryMacroGrams)
+			continue
+		}
+		rounded := calc.Round(*v, 1)
+		*macro.value = &rounded
+	}
+	return entry, errs
+}
+
+func validMealType(meal string) bool {
+	for _, m := range domain.MealTypes {
+		if m == meal {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+)
+
+type GoalHandler struct {
+	repo       *repository.GoalRepository
+	metricRepo *repository.MetricRepository
+	userRepo   *repository.UserRepository
+}
+
+func NewGoalHandler(
+	repo *repository.GoalRepository,
+	metricRepo *repository.MetricRepository,
+	userRepo *repository.UserRepository,
+) *GoalHandler {
+	return &GoalHandler{repo: repo, metricRepo: metricRepo, userRepo: userRepo}
+}
+
+func (h *GoalHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	var req domain.GoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if msg := checkGoalValues(req); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	today := time.Now().UTC()
+	targetDate, err := calc.ParseDate(req.TargetDate)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid target_date")
+		return
+	}
+	if !targetDate.After(today) {
+		writeError(w, http.StatusBadRequest, "target_date must be in the future")
+		return
+	}
+
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	metrics = calc.WithoutSuspects(metrics)
+
+	goal := domain.Goal{
+		UserID:      user.ID,
+		Type:        req.Type,
+		TargetValue: req.TargetValue,
+		StartDate:   today.Format(calc.DateLayout),
+		TargetDate:  targetDate.Format(calc.DateLayout),
+		Status:      domain.GoalStatusActive,
+	}
+	if weights := calc.DailyWeights(metrics); len(weights) > 0 {
+		goal.StartWeight = &weights[len(weights)-1].Value
+	}
+	if bmis := calc.DailyBMIs(metrics); len(bmis) > 0 {
+		goal.StartBMI = &bmis[len(bmis)-1].Value
+	}
+
+	switch {
+	case req.StartValue != nil:
+		goal.StartValue = *req.StartValue
+	case req.Type == domain.GoalTypeWeight && goal.StartWeight != nil:
+		goal.StartValue = *goal.StartWeight
+	case req.Type == domain.GoalTypeBMI && goal.StartBMI != nil:
+		goal.StartValue = *goal.StartBMI
+	default:
+		writeError(w, http.StatusBadRequest, "no metrics recorded yet; start_value is required")
+		return
+	}
+	if goal.StartValue == goal.TargetValue {
+		writeError(w, http.StatusBadRequest, "target_value must differ from the start value")
+		return
+	}
+
+	id, err := h.repo.Create(&goal)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create goal")
+		return
+	}
+
+	created, err := h.repo.GetByIDAndUserID(id, user.ID)
+	if err != nil || created == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get created goal")
+		return
+	}
+
+	progress := calc.GoalProgress(*created, calc.GoalSeries(created.Type, metrics), today)
+	created.Progress = &progress
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *GoalHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	goals, err := h.repo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list goals")
+		return
+	}
+	if goals == nil {
+		goals = []domain.Goal{}
+	}
+
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	metrics = calc.WithoutSuspects(metrics)
+
+	for i := range goals {
+		if goals[i].Status == domain.GoalStatusActive {
+			h.attachProgress(&goals[i], metrics)
+		}
+	}
+
+	writeJSONWithETag(w, r, http.StatusOK, "", goals)
+}
+
+func (h *GoalHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	goalID, err := strconv.ParseInt(mux.Vars(r)["goalId"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid goal id")
+		return
+	}
+
+	goal, err := h.repo.GetByIDAndUserID(goalID, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get goal")
+		return
+	}
+	if goal == nil {
+		writeError(w, http.StatusNotFound, "goal not found")
+		return
+	}
+
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	metrics = calc.WithoutSuspects(metrics)
+
+	h.attachProgress(goal, metrics)
+	writeJSON(w, http.StatusOK, goal)
+}
+
+func (h *GoalHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	goalID, err := strconv.ParseInt(mux.Vars(r)["goalId"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid goal id")
+		return
+	}
+
+	deleted, err := h.repo.DeleteByIDAndUserID(goalID, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete goal")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "goal not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// attachProgress fills in the goal's progress. Reads never change the goal;
+// service.GoalTracker marks it achieved when a metric write reaches it.
+func (h *GoalHandler) attachProgress(goal *domain.Goal, metrics []domain.UserMetric) {
+	progress := calc.GoalProgress(*goal, calc.GoalSeries(goal.Type, metrics), time.Now().UTC())
+	goal.Progress = &progress
+}
+
+// checkGoalValues validates the goal type and keeps the target and an
+// explicit start value within the same bounds. It returns a message for the
+// 400 response, or "".
+func checkGoalValues(req domain.GoalRequest) string {
+	var min, max float64
+	var name string
+	switch req.Type {
+	case domain.GoalTypeWeight:
+		min, max, name = 20, 500, "weight"
+	case domain.GoalTypeBMI:
+		min, max, name = 10, 70, "bmi"
+	default:
+		return "type must be weight or bmi"
+	}
+	inRange := func(v float64) bool { return v >= min && v <= max }
+
+	unit := ""
+	if req.Type == domain.GoalTypeWeight {
+		unit = " kg"
+	}
+	if !inRange(req.TargetValue) {
+		return fmt.Sprintf("target %s must be between %.0f and %.0f%s", name, min, max, unit)
+	}
+	if req.StartValue != nil && !inRange(*req.StartValue) {
+		return fmt.Sprintf("start %s must be between %.0f and %.0f%s", name, min, max, unit)
+	}
+	return ""
+}
+
+func (h *GoalHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	weeks, ok := queryInt(r, "weeks", 8, 2, 52)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "weeks must be between 2 and 52")
+		return
+	}
+	method := r.URL.Query().Get("method")
+	if method == "" {
+		method = calc.ForecastMethodOLS
+	}
+	if method != calc.ForecastMethodOLS && method != calc.ForecastMethodTheilSen {
+		writeError(w, http.StatusBadRequest, "method must be ols or theil-sen")
+		return
+	}
+
+	goal, err := h.repo.GetActiveByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get active goal")
+		return
+	}
+	if goal == nil {
+		writeError(w, http.StatusNotFound, "no active goal")
+		return
+	}
+
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	metrics = calc.WithoutSuspects(metrics)
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -7*weeks)
+	series := calc.Since(calc.GoalSeries(goal.Type, metrics), cutoff)
+
+	writeJSON(w, http.StatusOK, calc.Forecast(*goal, series, method, weeks))
+}
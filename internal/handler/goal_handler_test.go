@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func TestCheckGoalValues(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name   string
+		req    domain.GoalRequest
+		wantOK bool
+	}{
+		{"weight", domain.GoalRequest{Type: domain.GoalTypeWeight, TargetValue: 70}, true},
+		{"weight with start", domain.GoalRequest{Type: domain.GoalTypeWeight, TargetValue: 70, StartValue: f(85)}, true},
+		{"weight target low", domain.GoalRequest{Type: domain.GoalTypeWeight, TargetValue: 19}, false},
+		{"weight start high", domain.GoalRequest{Type: domain.GoalTypeWeight, TargetValue: 70, StartValue: f(501)}, false},
+		{"weight start zero", domain.GoalRequest{Type: domain.GoalTypeWeight, TargetValue: 70, StartValue: f(0)}, false},
+		{"bmi", domain.GoalRequest{Type: domain.GoalTypeBMI, TargetValue: 24.9, StartValue: f(28)}, true},
+		{"bmi start out of range", domain.GoalRequest{Type: domain.GoalTypeBMI, TargetValue: 24.9, StartValue: f(85)}, false},
+		{"unknown type", domain.GoalRequest{Type: "waist", TargetValue: 80}, false},
+	}
+	for _, tt := range tests {
+		if msg := checkGoalValues(tt.req); (msg == "") != tt.wantOK {
+			t.Errorf("%s: checkGoalValues = %q, want ok %v", tt.name, msg, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/importer"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+	"github.com/yusufkecer/body-metrics-backend/internal/service"
+)
+
+const uploadDeadline = 30 * time.Minute
+
+type ImportHandler struct {
+	importService *service.ImportService
+	jobRepo       *repository.ImportJobRepository
+	userRepo      *repository.UserRepository
+}
+
+func NewImportHandler(
+	importService *service.ImportService,
+	jobRepo *repository.ImportJobRepository,
+	userRepo *repository.UserRepository,
+) *ImportHandler {
+	return &ImportHandler{importService: importService, jobRepo: jobRepo, userRepo: userRepo}
+}
+
+func (h *ImportHandler) CSV(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	opts := importer.CSVOptions{
+		DateColumn:   valueOr(q.Get("date_column"), "date"),
+		WeightColumn: valueOr(q.Get("weight_column"), "weight"),
+		HeightColumn: valueOr(q.Get("height_column"), "height"),
+		DateFormat:   q.Get("date_format"),
+		WeightUnit:   pref.Weight,
+		HeightUnit:   pref.Height,
+	}
+	if d := q.Get("delimiter"); d != "" {
+		if d == `\t` || d == "tab" {
+			d = "\t"
+		}
+		if utf8.RuneCountInString(d) != 1 {
+			writeError(w, http.StatusBadRequest, "delimiter must be a single character")
+			return
+		}
+		opts.Delimiter, _ = utf8.DecodeRuneInString(d)
+	}
+
+	body, err := uploadedFile(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer body.Close()
+
+	records, invalid, err := importer.CSV{Options: opts}.Parse(body, time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.importService.Save(user, records, invalid)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to import metrics")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// File queues a background import for export formats that can be large, such
+// as Apple Health, Google Fit Takeout and Withings. The source comes from the
+// path, e.g. /imports/google-fit.
+func (h *ImportHandler) File(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	src, ok := importer.LookupFileSource(strings.ReplaceAll(mux.Vars(r)["source"], "-", "_"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "unsupported import source")
+		return
+	}
+
+	path, err := saveUpload(w, r, "import-*")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := h.importService.StartFile(user, src, path)
+	if err != nil {
+		os.Remove(path)
+		writeError(w, http.StatusInternalServerError, "failed to start import")
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/users/%d/imports/%d", user.ID, job.ID))
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+func (h *ImportHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	jobID, err := strconv.ParseInt(mux.Vars(r)["jobId"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid import id")
+		return
+	}
+
+	job, err := h.jobRepo.GetByIDAndUserID(jobID, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get import")
+		return
+	}
+	if job == nil {
+		writeError(w, http.StatusNotFound, "import not found")
+		return
+	}
+
+	if job.BytesTotal > 0 {
+		job.Progress = float64(job.BytesProcessed*1000/job.BytesTotal) / 10
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// uploadedFile accepts either a multipart form with a "file" field or the
+// raw file as the request body.
+func uploadedFile(r *http.Request) (io.ReadCloser, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, errors.New("multipart upload must include a file field")
+		}
+		return file, nil
+	}
+	return r.Body, nil
+}
+
+// saveUpload streams a large upload to a temporary file without buffering it
+// in memory, lifting the server's read deadline for the duration.
+func saveUpload(w http.ResponseWriter, r *http.Request, pattern string) (string, error) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Now().Add(uploadDeadline)); err != nil {
+		log.Printf("[upload] failed to extend read deadline: %v", err)
+	}
+	if err := rc.SetWriteDeadline(time.Now().Add(uploadDeadline)); err != nil {
+		log.Printf("[upload] failed to extend write deadline: %v", err)
+	}
+
+	src := io.Reader(r.Body)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return "", errors.New("invalid multipart body")
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return "", errors.New("multipart upload must include a file field")
+			}
+			if err != nil {
+				return "", errors.New("invalid multipart body")
+			}
+			if part.FormName() == "file" {
+				src = part
+				break
+			}
+		}
+	}
+
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to store upload")
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return "", fmt.Errorf("upload exceeds %d bytes", maxErr.Limit)
+		}
+		return "", errors.New("failed to read upload")
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", errors.New("failed to store upload")
+	}
+	return f.Name(), nil
+}
+
+func valueOr(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
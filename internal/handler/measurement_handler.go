@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+)
+
+type MeasurementHandler struct {
+	repo       *repository.MeasurementRepository
+	metricRepo *repository.MetricRepository
+	userRepo   *repository.UserRepository
+}
+
+func NewMeasurementHandler(
+	repo *repository.MeasurementRepository,
+	metricRepo *repository.MetricRepository,
+	userRepo *repository.UserRepository,
+) *MeasurementHandler {
+	return &MeasurementHandler{repo: repo, metricRepo: metricRepo, userRepo: userRepo}
+}
+
+func (h *MeasurementHandler) Kinds(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, domain.MeasurementKinds())
+}
+
+func (h *MeasurementHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	var req domain.MeasurementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	kind, ok := domain.LookupMeasurementKind(req.Kind)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "unknown measurement kind")
+		return
+	}
+	if req.Unit != "" && req.Unit != kind.Unit {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be recorded in %s", kind.Key, kind.Unit))
+		return
+	}
+
+	value := calc.Round(req.Value, kind.Precision)
+	if value < kind.Min || value > kind.Max {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%s must be between %g and %g %s", kind.Key, kind.Min, kind.Max, kind.Unit))
+		return
+	}
+
+	date := time.Now().UTC()
+	if req.Date != "" {
+		parsed, err := calc.ParseDate(req.Date)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid date")
+			return
+		}
+		if parsed.After(date) {
+			writeError(w, http.StatusBadRequest, "date cannot be in the future")
+			return
+		}
+		date = parsed
+	}
+
+	m := domain.Measurement{
+		UserID: user.ID,
+		Kind:   kind.Key,
+		Value:  value,
+		Unit:   kind.Unit,
+		Date:   date.Format(calc.DateLayout),
+	}
+
+	id, err := h.repo.Create(&m)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create measurement")
+		return
+	}
+
+	created, err := h.repo.GetByIDAndUserID(id, user.ID)
+	if err != nil || created == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get created measurement")
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *MeasurementHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	kind := r.URL.Query().Get("kind")
+	if kind != "" {
+		if _, ok := domain.LookupMeasurementKind(kind); !ok {
+			writeError(w, http.StatusBadRequest, "unknown measurement kind")
+			return
+		}
+	}
+
+	measurements, err := h.repo.GetByUserID(user.ID, kind)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list measurements")
+		return
+	}
+	if measurements == nil {
+		measurements = []domain.Measurement{}
+	}
+
+	writeJSONWithETag(w, r, http.StatusOK, "", measurements)
+}
+
+func (h *MeasurementHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	measurementID, err := strconv.ParseInt(mux.Vars(r)["measurementId"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid measurement id")
+		return
+	}
+
+	deleted, err := h.repo.DeleteByIDAndUserID(measurementID, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete measurement")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "measurement not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MeasurementHandler) Indicators(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	latest, err := h.repo.GetLatestByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list measurements")
+		return
+	}
+
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	metrics = calc.WithoutSuspects(metrics)
+
+	in := calc.CompositionInput{Gender: user.Gender}
+	in.HeightCM = user.Height
+	if weights := calc.DailyWeights(metrics); len(weights) > 0 {
+		in.WeightKG = &weights[len(weights)-1].Value
+	}
+	pick := func(kind string) *float64 {
+		if m, ok := latest[kind]; ok {
+			return &m.Value
+		}
+		return nil
+	}
+	in.WaistCM = pick(domain.MeasurementWaist)
+	in.HipCM = pick(domain.MeasurementHip)
+	in.NeckCM = pick(domain.MeasurementNeck)
+	in.BodyFat = pick(domain.MeasurementBodyFat)
+
+	writeJSON(w, http.StatusOK, calc.BodyIndicators(in))
+}
@@ -1,26 +1,41 @@
 package handler
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
 	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/export"
 	"github.com/yusufkecer/body-metrics-backend/internal/middleware"
 	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+	"github.com/yusufkecer/body-metrics-backend/internal/service"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+	"github.com/yusufkecer/body-metrics-backend/internal/validation"
 )
 
 type MetricHandler struct {
-	repo     *repository.MetricRepository
-	userRepo *repository.UserRepository
+	repo           *repository.MetricRepository
+	userRepo       *repository.UserRepository
+	goals          *service.GoalTracker
+	requireIfMatch bool
 }
 
 func NewMetricHandler(
 	repo *repository.MetricRepository,
 	userRepo *repository.UserRepository,
+	goals *service.GoalTracker,
+	requireIfMatch bool,
 ) *MetricHandler {
-	return &MetricHandler{repo: repo, userRepo: userRepo}
+	return &MetricHandler{repo: repo, userRepo: userRepo, goals: goals, requireIfMatch: requireIfMatch}
 }
 
 func (h *MetricHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -46,21 +61,54 @@ func (h *MetricHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var metric domain.UserMetric
-	if err := json.NewDecoder(r.Body).Decode(&metric); err != nil {
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input metricInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
+	pref, unitErrs := bodyUnits(pref, input.WeightUnit, input.HeightUnit)
+	if len(unitErrs) > 0 {
+		writeValidationError(w, unitErrs)
+		return
+	}
+
+	metric := input.UserMetric
 	metric.UserID = userID
+	metric.UUID, metric.Version = "", 0
+	// An entry sent with confirm is never flagged as suspect.
+	metric.Confirmed = input.Confirm
+	ftIn := metric.HeightFtIn
+	units.MetricToSI(&metric, pref)
+	if ftIn != nil {
+		cm, fieldErr := feetInchesToCM(*ftIn, metric.Height != 0)
+		if fieldErr != nil {
+			writeValidationError(w, []domain.FieldError{*fieldErr})
+			return
+		}
+		metric.Height = cm
+	}
+	if !checkMetric(w, &metric, user, input.Confirm) {
+		return
+	}
+	calc.DeriveBodyMetric(&metric, user)
 
-	id, err := h.repo.Create(&metric)
-	if err != nil {
+	metric.WeightDiff = nil
+	if _, err := h.repo.Create(&metric, func(all []domain.UserMetric) {
+		calc.DeriveHistory(all, user)
+	}); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create metric")
 		return
 	}
+	h.goals.Check(user.ID)
 
-	metric.ID = id
+	units.MetricFromSI(&metric, pref)
 	writeJSON(w, http.StatusCreated, metric)
 }
 
@@ -87,14 +135,404 @@ func (h *MetricHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	metrics, err := h.repo.GetByUserID(userID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list metrics")
 		return
 	}
+	if r.URL.Query().Get("include_suspect") != "true" {
+		metrics = calc.WithoutSuspects(metrics)
+	}
+	tags, ok := queryTags(w, r, "tag")
+	if !ok {
+		return
+	}
+	if len(tags) > 0 {
+		metrics = calc.WithAnyTag(metrics, tags)
+	}
 	if metrics == nil {
 		metrics = []domain.UserMetric{}
 	}
+	for i := range metrics {
+		calc.AnnotateBMIForAge(&metrics[i], user)
+		units.MetricFromSI(&metrics[i], pref)
+	}
 
-	writeJSON(w, http.StatusOK, metrics)
+	writeJSONWithETag(w, r, http.StatusOK, "", metrics)
+}
+
+// metricInput is a metric submission. weight_unit and height_unit in the
+// body name the units of its values. Confirm accepts values that were flagged
+// as implausible on an earlier attempt.
+type metricInput struct {
+	domain.UserMetric
+	Confirm bool `json:"confirm"`
+}
+
+// metricPatch holds the fields a PATCH may change. An empty note clears it
+// and tags replace the entry's tags as a whole. weight_unit and height_unit
+// name the units of weight and height as in metricInput.
+type metricPatch struct {
+	Date       *string   `json:"date"`
+	Weight     *float64  `json:"weight"`
+	Height     *float64  `json:"height"`
+	HeightFtIn *string   `json:"height_ft_in"`
+	Note       *string   `json:"note"`
+	Tags       *[]string `json:"tags"`
+	WeightUnit string    `json:"weight_unit"`
+	HeightUnit string    `json:"height_unit"`
+	Confirm    bool      `json:"confirm"`
+}
+
+// checkMetric validates m and writes the 422 response when it cannot be
+// stored. Confirmed warnings are attached to m for the response.
+func checkMetric(w http.ResponseWriter, m *domain.UserMetric, user *domain.User, confirm bool) bool {
+	errs, warnings := validation.Metric(m, user, time.Now().UTC())
+	if len(errs) > 0 {
+		writeValidationError(w, errs)
+		return false
+	}
+	if len(warnings) > 0 && !confirm {
+		writeConfirmationRequired(w, warnings)
+		return false
+	}
+	m.Warnings = warnings
+	return true
+}
+
+func (h *MetricHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	user, metric, ok := h.ownedMetric(w, r)
+	if !ok {
+		return
+	}
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	etag := metricETag(*metric, user, pref)
+	calc.AnnotateBMIForAge(metric, user)
+	units.MetricFromSI(metric, pref)
+	writeJSONWithETag(w, r, http.StatusOK, etag, metric)
+}
+
+func (h *MetricHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user, metric, ok := h.ownedMetric(w, r)
+	if !ok {
+		return
+	}
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !checkIfMatch(w, r, metricETag(*metric, user, pref), h.requireIfMatch) {
+		return
+	}
+
+	var patch metricPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	pref, unitErrs := bodyUnits(pref, patch.WeightUnit, patch.HeightUnit)
+	if len(unitErrs) > 0 {
+		writeValidationError(w, unitErrs)
+		return
+	}
+	if patch.Date != nil {
+		metric.Date = *patch.Date
+	}
+	if patch.Weight != nil {
+		kg := units.WeightToKG(*patch.Weight, pref.Weight)
+		metric.Weight = &kg
+	}
+	if patch.Height != nil {
+		metric.Height = *patch.Height
+		if metric.Height > 0 {
+			metric.Height = units.HeightToCM(*patch.Height, pref.Height)
+		}
+	}
+	if patch.HeightFtIn != nil {
+		cm, fieldErr := feetInchesToCM(*patch.HeightFtIn, patch.Height != nil)
+		if fieldErr != nil {
+			writeValidationError(w, []domain.FieldError{*fieldErr})
+			return
+		}
+		metric.Height = cm
+	}
+	if patch.Note != nil {
+		metric.Note = patch.Note
+	}
+	if patch.Tags != nil {
+		metric.Tags = *patch.Tags
+	}
+	metric.BodyMetric = nil
+	if !checkMetric(w, metric, user, patch.Confirm) {
+		return
+	}
+	// Re-entering the weight or confirming the entry marks it as correct, so
+	// it is no longer flagged as suspect.
+	if patch.Confirm || patch.Weight != nil {
+		metric.Confirmed = true
+	}
+
+	if err := h.repo.Update(metric, h.expectedVersion(r, metric), func(all []domain.UserMetric) {
+		calc.DeriveHistory(all, user)
+	}); err != nil {
+		h.writeModifyError(w, err, "failed to update metric")
+		return
+	}
+	h.goals.Check(user.ID)
+
+	// Stored tags are listed by name, so the response matches a later GET.
+	sort.Strings(metric.Tags)
+	w.Header().Set("ETag", metricETag(*metric, user, pref))
+	calc.AnnotateBMIForAge(metric, user)
+	units.MetricFromSI(metric, pref)
+	writeJSON(w, http.StatusOK, metric)
+}
+
+func (h *MetricHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, metric, ok := h.ownedMetric(w, r)
+	if !ok {
+		return
+	}
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !checkIfMatch(w, r, metricETag(*metric, user, pref), h.requireIfMatch) {
+		return
+	}
+
+	if err := h.repo.Delete(user.ID, metric.ID, h.expectedVersion(r, metric), func(all []domain.UserMetric) {
+		calc.DeriveHistory(all, user)
+	}); err != nil {
+		h.writeModifyError(w, err, "failed to delete metric")
+		return
+	}
+	h.goals.Check(user.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MetricHandler) ownedMetric(w http.ResponseWriter, r *http.Request) (*domain.User, *domain.UserMetric, bool) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return nil, nil, false
+	}
+	metricID, err := strconv.ParseInt(mux.Vars(r)["metricId"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid metric id")
+		return nil, nil, false
+	}
+
+	metric, err := h.repo.GetByIDAndUserID(metricID, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get metric")
+		return nil, nil, false
+	}
+	if metric == nil {
+		writeError(w, http.StatusNotFound, "metric not found")
+		return nil, nil, false
+	}
+	return user, metric, true
+}
+
+// expectedVersion pins a write to the version the client matched so a
+// concurrent edit between the check and the write still fails.
+func (h *MetricHandler) expectedVersion(r *http.Request, metric *domain.UserMetric) int64 {
+	if r.Header.Get("If-Match") == "" {
+		return 0
+	}
+	return metric.Version
+}
+
+func (h *MetricHandler) writeModifyError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrVersionMismatch):
+		writeError(w, http.StatusPreconditionFailed, "resource has been modified")
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, "metric not found")
+	default:
+		writeError(w, http.StatusInternalServerError, message)
+	}
+}
+
+func (h *MetricHandler) Trend(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	window, ok := queryInt(r, "window", 7, 1, 90)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "window must be between 1 and 90")
+		return
+	}
+	alpha, ok := queryFloat(r, "alpha", 0.1, 0.01, 1)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "alpha must be between 0.01 and 1")
+		return
+	}
+
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	metrics, err := h.repo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	metrics = calc.WithoutSuspects(metrics)
+
+	points := calc.Trend(calc.DailyWeights(metrics), window, alpha)
+	units.TrendFromSI(points, pref.Weight)
+
+	writeJSON(w, http.StatusOK, domain.MetricTrend{
+		WeightUnit: pref.Weight,
+		Window:     window,
+		Alpha:      alpha,
+		Points:     points,
+	})
+}
+
+// Summary describes the weigh-ins of the last days days (all when omitted),
+// leaving suspect entries and those carrying an exclude_tag out.
+func (h *MetricHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	days, ok := queryInt(r, "days", 0, 1, 3650)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "days must be between 1 and 3650")
+		return
+	}
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	excluded, ok := queryTags(w, r, "exclude_tag")
+	if !ok {
+		return
+	}
+
+	metrics, err := h.repo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	metrics = calc.WithoutTags(metrics, excluded)
+
+	var since time.Time
+	if days > 0 {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		since = today.AddDate(0, 0, -(days - 1))
+	}
+	summary := calc.Summarize(metrics, since)
+	summary.Days = days
+	units.SummaryFromSI(&summary, pref.Weight)
+	writeJSONWithETag(w, r, http.StatusOK, "", summary)
+}
+
+// Export streams the metric history as csv, json or xlsx, optionally limited
+// to the inclusive from/to date range and converted to the requested units.
+func (h *MetricHandler) Export(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	format := valueOr(q.Get("format"), export.FormatCSV)
+	spec, ok := export.LookupFormat(format)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "format must be csv, json or xlsx")
+		return
+	}
+
+	var from, to time.Time
+	var err error
+	if v := q.Get("from"); v != "" {
+		if from, err = calc.ParseDate(v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid from date")
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = calc.ParseDate(v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid to date")
+			return
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		writeError(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The writer is opened on the first row so a failing query can still be
+	// reported as an error response.
+	var out export.Writer
+	open := func() error {
+		if out != nil {
+			return nil
+		}
+		w.Header().Set("Content-Type", spec.ContentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="metrics-%d.%s"`, user.ID, spec.Extension))
+		out, err = export.NewWriter(format, w, pref.Weight, pref.Height)
+		return err
+	}
+
+	err = h.repo.Stream(user.ID, func(m domain.UserMetric) error {
+		// A row whose date does not parse can't be placed in a range, so
+		// it is only exported when no range was asked for.
+		if !from.IsZero() || !to.IsZero() {
+			day, err := calc.ParseDate(m.Date)
+			if err != nil || (!from.IsZero() && day.Before(from)) || (!to.IsZero() && day.After(to)) {
+				return nil
+			}
+		}
+		if err := open(); err != nil {
+			return err
+		}
+		calc.AnnotateBMIForAge(&m, user)
+		units.MetricFromSI(&m, pref)
+		return out.Write(m)
+	})
+	if err != nil {
+		if out == nil {
+			writeError(w, http.StatusInternalServerError, "failed to export metrics")
+			return
+		}
+		log.Printf("[export] user %d: %v", user.ID, err)
+		return
+	}
+
+	if err := open(); err != nil {
+		log.Printf("[export] user %d: %v", user.ID, err)
+		return
+	}
+	if err := out.Close(); err != nil {
+		log.Printf("[export] user %d: %v", user.ID, err)
+	}
 }
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/validation"
+)
+
+// entryDate reads the optional date of a logged entry, which defaults to
+// today and may not lie in the future.
+func entryDate(raw string, now time.Time) (time.Time, *domain.FieldError) {
+	if raw == "" {
+		return now, nil
+	}
+	parsed, err := calc.ParseDate(raw)
+	if err != nil {
+		return time.Time{}, &domain.FieldError{Field: "date", Code: domain.FieldBadFormat, Message: "date is not a recognised date"}
+	}
+	if parsed.After(now.Add(validation.FutureDateTolerance)) {
+		return time.Time{}, &domain.FieldError{Field: "date", Code: domain.FieldInFuture, Message: "date must not be in the future"}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/middleware"
+)
+
+// userLookup is the part of repository.UserRepository that ownedUser needs.
+type userLookup interface {
+	GetByIDAndAccountID(id, accountID int64) (*domain.User, error)
+}
+
+func ownedUser(w http.ResponseWriter, r *http.Request, userRepo userLookup) (*domain.User, bool) {
+	accountID, ok := r.Context().Value(middleware.AccountIDKey).(int64)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "invalid account context")
+		return nil, false
+	}
+
+	userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user id")
+		return nil, false
+	}
+
+	user, err := userRepo.GetByIDAndAccountID(userID, accountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to validate user ownership")
+		return nil, false
+	}
+	if user == nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return nil, false
+	}
+	return user, true
+}
+
+func queryInt(r *http.Request, key string, fallback, min, max int) (int, bool) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return fallback, true
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < min || v > max {
+		return 0, false
+	}
+	return v, true
+}
+
+func queryFloat(r *http.Request, key string, fallback, min, max float64) (float64, bool) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return fallback, true
+	}
+	// ParseFloat accepts "NaN" and "Inf"; NaN would slip past the range
+	// check because every comparison with it is false.
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) || v < min || v > max {
+		return 0, false
+	}
+	return v, true
+}
+
+// queryTags reads a repeatable tag parameter, which may also hold a
+// comma-separated list, and writes the 400 response for an invalid name.
+func queryTags(w http.ResponseWriter, r *http.Request, key string) ([]string, bool) {
+	var tags []string
+	for _, v := range r.URL.Query()[key] {
+		for _, raw := range strings.Split(v, ",") {
+			name, ok := domain.NormalizeTag(raw)
+			if !ok {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s %q", key, raw))
+				return nil, false
+			}
+			tags = append(tags, name)
+		}
+	}
+	return tags, true
+}
+
+// queryDateRange reads an inclusive from/to date range. Without from it
+// covers the last days days up to to (default today), and it may span at most
+// maxDays days. The 400 response is written when the range is invalid.
+func queryDateRange(w http.ResponseWriter, r *http.Request, defaultDays, maxDays int) (time.Time, time.Time, bool) {
+	q := r.URL.Query()
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v := q.Get("to"); v != "" {
+		t, err := calc.ParseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid to date")
+			return time.Time{}, time.Time{}, false
+		}
+		to = t
+	}
+	days, ok := queryInt(r, "days", defaultDays, 1, maxDays)
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("days must be between 1 and %d", maxDays))
+		return time.Time{}, time.Time{}, false
+	}
+	from := to.AddDate(0, 0, -(days - 1))
+	if v := q.Get("from"); v != "" {
+		t, err := calc.ParseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid from date")
+			return time.Time{}, time.Time{}, false
+		}
+		from = t
+	}
+	if to.Before(from) {
+		writeError(w, http.StatusBadRequest, "to must not be before from")
+		return time.Time{}, time.Time{}, false
+	}
+	if calc.DaysBetween(from, to) >= maxDays {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("range must not exceed %d days", maxDays))
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
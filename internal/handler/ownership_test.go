@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryFloat(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   float64
+		wantOK bool
+	}{
+		{"", 0.1, true},
+		{"alpha=0.5", 0.5, true},
+		{"alpha=1", 1, true},
+		{"alpha=0.001", 0, false},
+		{"alpha=2", 0, false},
+		{"alpha=abc", 0, false},
+		{"alpha=NaN", 0, false},
+		{"alpha=nan", 0, false},
+		{"alpha=Inf", 0, false},
+		{"alpha=-Inf", 0, false},
+		{"alpha=%2BInf", 0, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/?"+tt.query, nil)
+		got, ok := queryFloat(r, "alpha", 0.1, 0.01, 1)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("queryFloat(%q) = %v, %v; want %v, %v", tt.query, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
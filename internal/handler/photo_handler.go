@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/imaging"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+	"github.com/yusufkecer/body-metrics-backend/internal/storage"
+	"github.com/yusufkecer/body-metrics-backend/internal/uuid"
+)
+
+const (
+	// MaxPhotoUpload is the body limit for photo uploads; main.go registers
+	// it as an override of the global limit.
+	MaxPhotoUpload = 15 << 20
+
+	photoMaxEdge   = 2048
+	photoThumbSize = 256
+	photoQuality   = 85
+)
+
+// photoRepository and metricLookup are the parts of the repositories the
+// photo handler uses, so tests can run it without a database.
+type photoRepository interface {
+	Create(p *domain.Photo) (int64, error)
+	GetByUserID(userID, metricID int64) ([]domain.Photo, error)
+	GetByIDAndUserID(id, userID int64) (*domain.Photo, error)
+	DeleteByIDAndUserID(id, userID int64) (bool, error)
+}
+
+type metricLookup interface {
+	GetByIDAndUserID(id, userID int64) (*domain.UserMetric, error)
+}
+
+type PhotoHandler struct {
+	repo       photoRepository
+	metricRepo metricLookup
+	userRepo   userLookup
+	store      storage.Store
+}
+
+func NewPhotoHandler(
+	repo *repository.PhotoRepository,
+	metricRepo *repository.MetricRepository,
+	userRepo *repository.UserRepository,
+	store storage.Store,
+) *PhotoHandler {
+	return &PhotoHandler{repo: repo, metricRepo: metricRepo, userRepo: userRepo, store: store}
+}
+
+// Upload accepts a multipart form with the image in "file" and either a
+// "metric_id" to attach it to a weigh-in or a "date" (default today). The
+// image is re-encoded as an upright JPEG, which drops EXIF data such as the
+// GPS location, and a square thumbnail is stored next to it.
+func (h *PhotoHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseMultipartForm(MaxPhotoUpload); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("photo must not exceed %d MB", MaxPhotoUpload>>20))
+			return
+		}
+		writeError(w, http.StatusBadRequest, "photo must be sent as multipart/form-data")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	photo := domain.Photo{UserID: user.ID}
+	date := time.Now().UTC()
+	if v := r.FormValue("metric_id"); v != "" {
+		metricID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid metric id")
+			return
+		}
+		metric, err := h.metricRepo.GetByIDAndUserID(metricID, user.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get metric")
+			return
+		}
+		if metric == nil {
+			writeError(w, http.StatusNotFound, "metric not found")
+			return
+		}
+		if date, err = calc.ParseDate(metric.Date); err != nil {
+			writeError(w, http.StatusInternalServerError, "metric has an invalid date")
+			return
+		}
+		photo.MetricID = &metric.ID
+	} else if v := r.FormValue("date"); v != "" {
+		parsed, err := calc.ParseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid date")
+			return
+		}
+		if parsed.After(date) {
+			writeError(w, http.StatusBadRequest, "date cannot be in the future")
+			return
+		}
+		date = parsed
+	}
+	photo.Date = date.Format(calc.DateLayout)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "multipart upload must include a file field")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read photo")
+		return
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		writeError(w, http.StatusUnsupportedMediaType, err.Error())
+		return
+	}
+
+	var full, thumb bytes.Buffer
+	fitted := imaging.Fit(img, photoMaxEdge)
+	if err := imaging.EncodeJPEG(&full, fitted, photoQuality); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode photo")
+		return
+	}
+	if err := imaging.EncodeJPEG(&thumb, imaging.Square(img, photoThumbSize), photoQuality); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode thumbnail")
+		return
+	}
+	photo.Width = fitted.Bounds().Dx()
+	photo.Height = fitted.Bounds().Dy()
+	photo.SizeBytes = int64(full.Len())
+
+	name := uuid.New()
+	photo.BlobKey = fmt.Sprintf("photos/%d/%s.jpg", user.ID, name)
+	photo.ThumbKey = fmt.Sprintf("photos/%d/%s_thumb.jpg", user.ID, name)
+	if err := h.store.Put(r.Context(), photo.BlobKey, &full); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to store photo")
+		return
+	}
+	if err := h.store.Put(r.Context(), photo.ThumbKey, &thumb); err != nil {
+		h.deleteBlobs(&photo)
+		writeError(w, http.StatusInternalServerError, "failed to store photo")
+		return
+	}
+
+	id, err := h.repo.Create(&photo)
+	if err != nil {
+		h.deleteBlobs(&photo)
+		writeError(w, http.StatusInternalServerError, "failed to create photo")
+		return
+	}
+
+	created, err := h.repo.GetByIDAndUserID(id, user.ID)
+	if err != nil || created == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get created photo")
+		return
+	}
+	withPhotoURLs(created)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *PhotoHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	var metricID int64
+	if v := r.URL.Query().Get("metric_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid metric id")
+			return
+		}
+		metricID = id
+	}
+
+	photos, err := h.repo.GetByUserID(user.ID, metricID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list photos")
+		return
+	}
+	if photos == nil {
+		photos = []domain.Photo{}
+	}
+	for i := range photos {
+		withPhotoURLs(&photos[i])
+	}
+
+	writeJSONWithETag(w, r, http.StatusOK, "", photos)
+}
+
+func (h *PhotoHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	photo, ok := h.ownedPhoto(w, r)
+	if !ok {
+		return
+	}
+	withPhotoURLs(photo)
+	writeJSON(w, http.StatusOK, photo)
+}
+
+// Image serves the stored JPEG, or its thumbnail when the path ends in
+// /thumbnail. Blob keys never change, so clients may cache them for good.
+func (h *PhotoHandler) Image(w http.ResponseWriter, r *http.Request) {
+	photo, ok := h.ownedPhoto(w, r)
+	if !ok {
+		return
+	}
+
+	key := photo.BlobKey
+	if mux.Vars(r)["variant"] == "thumbnail" {
+		key = photo.ThumbKey
+	}
+	serveBlob(w, r, h.store, key, "private, max-age=31536000, immutable")
+}
+
+func (h *PhotoHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	photo, ok := h.ownedPhoto(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.repo.DeleteByIDAndUserID(photo.ID, photo.UserID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete photo")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "photo not found")
+		return
+	}
+	h.deleteBlobs(photo)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PhotoHandler) ownedPhoto(w http.ResponseWriter, r *http.Request) (*domain.Photo, bool) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return nil, false
+	}
+	photoID, err := strconv.ParseInt(mux.Vars(r)["photoId"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid photo id")
+		return nil, false
+	}
+
+	photo, err := h.repo.GetByIDAndUserID(photoID, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get photo")
+		return nil, false
+	}
+	if photo == nil {
+		writeError(w, http.StatusNotFound, "photo not found")
+		return nil, false
+	}
+	return photo, true
+}
+
+// deleteBlobs removes a photo's files on a best-effort basis; an orphaned
+// blob is only wasted space.
+func (h *PhotoHandler) deleteBlobs(photo *domain.Photo) {
+	for _, key := range []string{photo.BlobKey, photo.ThumbKey} {
+		if err := h.store.Delete(context.Background(), key); err != nil {
+			log.Printf("[photo] failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+func withPhotoURLs(p *domain.Photo) {
+	base := fmt.Sprintf("/api/v1/users/%d/photos/%d", p.UserID, p.ID)
+	p.URL = base + "/image"
+	p.ThumbnailURL = base + "/thumbnail"
+}
+
+func serveBlob(w http.ResponseWriter, r *http.Request, store storage.Store, key, cacheControl string) {
+	body, info, err := store.Get(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read image")
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.Header().Set("Cache-Control", cacheControl)
+	if !info.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		io.Copy(w, body)
+	}
+}
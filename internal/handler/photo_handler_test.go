@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/middleware"
+	"github.com/yusufkecer/body-metrics-backend/internal/storage"
+)
+
+const (
+	testAccountID = 1
+	testUserID    = 7
+)
+
+type fakeUsers struct{}
+
+func (fakeUsers) GetByIDAndAccountID(id, accountID int64) (*domain.User, error) {
+	if id != testUserID || accountID != testAccountID {
+		return nil, nil
+	}
+	return &domain.User{ID: id, WeightUnit: "kg", HeightUnit: "cm"}, nil
+}
+
+type fakeMetrics struct{}
+
+func (fakeMetrics) GetByIDAndUserID(id, userID int64) (*domain.UserMetric, error) {
+	return nil, nil
+}
+
+type fakePhotos struct {
+	mu     sync.Mutex
+	nextID int64
+	photos map[int64]domain.Photo
+}
+
+func newFakePhotos() *fakePhotos {
+	return &fakePhotos{photos: make(map[int64]domain.Photo)}
+}
+
+func (f *fakePhotos) Create(p *domain.Photo) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	p.ID = f.nextID
+	f.photos[p.ID] = *p
+	return p.ID, nil
+}
+
+func (f *fakePhotos) GetByUserID(userID, metricID int64) ([]domain.Photo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []domain.Photo
+	for _, p := range f.photos {
+		if p.UserID == userID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakePhotos) GetByIDAndUserID(id, userID int64) (*domain.Photo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.photos[id]
+	if !ok || p.UserID != userID {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+func (f *fakePhotos) DeleteByIDAndUserID(id, userID int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.photos[id]
+	if !ok || p.UserID != userID {
+		return false, nil
+	}
+	delete(f.photos, id)
+	return true, nil
+}
+
+// photoRouter wires the photo routes the way main.go does, including the
+// body limit override for uploads.
+func photoRouter(h *PhotoHandler) http.Handler {
+	r := mux.NewRouter()
+	r.Use(middleware.BodyLimit(1<<20, map[string]int64{"photo-upload": MaxPhotoUpload}))
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ctx := context.WithValue(req.Context(), middleware.AccountIDKey, int64(testAccountID))
+			next.ServeHTTP(w, req.WithContext(ctx))
+		})
+	})
+	r.HandleFunc("/users/{id}/photos", h.Upload).Methods(http.MethodPost).Name("photo-upload")
+	r.HandleFunc("/users/{id}/photos/{photoId:[0-9]+}", h.Delete).Methods(http.MethodDelete)
+	return r
+}
+
+func newTestPhotoHandler() (*PhotoHandler, *fakePhotos, *storage.Memory) {
+	photos, store := newFakePhotos(), storage.NewMemory()
+	return &PhotoHandler{repo: photos, metricRepo: fakeMetrics{}, userRepo: fakeUsers{}, store: store}, photos, store
+}
+
+func uploadRequest(t *testing.T, filename string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	form.WriteField("date", "2024-05-01")
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/users/7/photos", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+// jpegWithGPS encodes a small JPEG and inserts an EXIF APP1 segment with a
+// GPS IFD right after the start-of-image marker.
+func jpegWithGPS(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 4), uint8(y * 5), 128, 255})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	le := binary.LittleEndian
+	tiff := []byte("II*\x00")
+	tiff = le.AppendUint32(tiff, 8)
+	// IFD0: one GPSInfo entry pointing at the GPS IFD at offset 26.
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = le.AppendUint16(tiff, 0x8825)
+	tiff = le.AppendUint16(tiff, 4)
+	tiff = le.AppendUint32(tiff, 1)
+	tiff = le.AppendUint32(tiff, 26)
+	tiff = le.AppendUint32(tiff, 0)
+	// GPS IFD: GPSLatitudeRef = "N".
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = le.AppendUint16(tiff, 0x0001)
+	tiff = le.AppendUint16(tiff, 2)
+	tiff = le.AppendUint32(tiff, 2)
+	tiff = append(tiff, 'N', 0, 0, 0)
+	tiff = le.AppendUint32(tiff, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := encoded.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// jpegMarkers lists the segment markers of a JPEG up to the start of scan.
+func jpegMarkers(t *testing.T, data []byte) []byte {
+	t.Helper()
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		t.Fatal("not a JPEG")
+	}
+	var markers []byte
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			t.Fatalf("bad marker at %d", i)
+		}
+		marker := data[i+1]
+		markers = append(markers, marker)
+		if marker == 0xDA {
+			break
+		}
+		i += 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+	}
+	return markers
+}
+
+func hasMarker(markers []byte, marker byte) bool {
+	return bytes.IndexByte(markers, marker) >= 0
+}
+
+func TestPhotoUploadStripsEXIF(t *testing.T) {
+	h, photos, store := newTestPhotoHandler()
+	input := jpegWithGPS(t)
+	if !hasMarker(jpegMarkers(t, input), 0xE1) {
+		t.Fatal("test image has no APP1 segment")
+	}
+
+	rec := httptest.NewRecorder()
+	photoRouter(h).ServeHTTP(rec, uploadRequest(t, "gps.jpg", input))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	photo, _ := photos.GetByIDAndUserID(1, testUserID)
+	if photo == nil {
+		t.Fatal("photo was not recorded")
+	}
+	for _, key := range []string{photo.BlobKey, photo.ThumbKey} {
+		body, _, err := store.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("blob %s: %v", key, err)
+		}
+		var stored bytes.Buffer
+		stored.ReadFrom(body)
+		body.Close()
+		if hasMarker(jpegMarkers(t, stored.Bytes()), 0xE1) {
+			t.Errorf("blob %s still has an APP1 segment", key)
+		}
+		if bytes.Contains(stored.Bytes(), []byte("Exif")) {
+			t.Errorf("blob %s still contains EXIF data", key)
+		}
+	}
+	if photo.Width != 64 || photo.Height != 48 {
+		t.Errorf("size = %dx%d, want 64x48", photo.Width, photo.Height)
+	}
+}
+
+func TestPhotoUploadTooLarge(t *testing.T) {
+	h, _, store := newTestPhotoHandler()
+	rec := httptest.NewRecorder()
+	photoRouter(h).ServeHTTP(rec, uploadRequest(t, "big.jpg", make([]byte, MaxPhotoUpload+1)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413; body = %s", rec.Code, rec.Body)
+	}
+	if keys := store.Keys(); len(keys) != 0 {
+		t.Errorf("stored blobs = %v, want none", keys)
+	}
+}
+
+func TestPhotoUploadRejectsNonImage(t *testing.T) {
+	h, _, store := newTestPhotoHandler()
+	rec := httptest.NewRecorder()
+	photoRouter(h).ServeHTTP(rec, uploadRequest(t, "notes.txt", []byte(strings.Repeat("not an image\n", 100))))
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want 415; body = %s", rec.Code, rec.Body)
+	}
+	if keys := store.Keys(); len(keys) != 0 {
+		t.Errorf("stored blobs = %v, want none", keys)
+	}
+}
+
+func TestPhotoDeleteRemovesBlobs(t *testing.T) {
+	h, photos, store := newTestPhotoHandler()
+	router := photoRouter(h)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, uploadRequest(t, "photo.jpg", jpegWithGPS(t)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if keys := store.Keys(); len(keys) != 2 {
+		t.Fatalf("stored blobs = %v, want image and thumbnail", keys)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/users/7/photos/1", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, body = %s", rec.Code, rec.Body)
+	}
+	if keys := store.Keys(); len(keys) != 0 {
+		t.Errorf("stored blobs after delete = %v, want none", keys)
+	}
+	if p, _ := photos.GetByIDAndUserID(1, testUserID); p != nil {
+		t.Error("photo row was not deleted")
+	}
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/report"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+)
+
+type ReportHandler struct {
+	userRepo        *repository.UserRepository
+	metricRepo      *repository.MetricRepository
+	measurementRepo *repository.MeasurementRepository
+	goalRepo        *repository.GoalRepository
+}
+
+func NewReportHandler(
+	userRepo *repository.UserRepository,
+	metricRepo *repository.MetricRepository,
+	measurementRepo *repository.MeasurementRepository,
+	goalRepo *repository.GoalRepository,
+) *ReportHandler {
+	return &ReportHandler{
+		userRepo:        userRepo,
+		metricRepo:      metricRepo,
+		measurementRepo: measurementRepo,
+		goalRepo:        goalRepo,
+	}
+}
+
+// PDF renders the one-page progress report. The period defaults to the last
+// `days` (90) days and can be set explicitly with from/to.
+func (h *ReportHandler) PDF(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v := q.Get("to"); v != "" {
+		t, err := calc.ParseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid to date")
+			return
+		}
+		to = t
+	}
+	days, ok := queryInt(r, "days", 90, 7, 3650)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "days must be between 7 and 3650")
+		return
+	}
+	from := to.AddDate(0, 0, -(days - 1))
+	if v := q.Get("from"); v != "" {
+		t, err := calc.ParseDate(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid from date")
+			return
+		}
+		from = t
+	}
+	if to.Before(from) {
+		writeError(w, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	metrics, err := h.metricRepo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list metrics")
+		return
+	}
+	metrics = calc.WithoutSuspects(metrics)
+	latest, err := h.measurementRepo.GetLatestByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list measurements")
+		return
+	}
+	goal, err := h.goalRepo.GetActiveByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get active goal")
+		return
+	}
+
+	data := report.Data{
+		User:        *user,
+		Lang:        report.Language(q.Get("lang"), r.Header.Get("Accept-Language")),
+		Units:       pref,
+		From:        from,
+		To:          to,
+		GeneratedAt: now,
+		Weights:     between(calc.DailyWeights(metrics), from, to),
+		BMIs:        between(calc.DailyBMIs(metrics), from, to),
+		Goal:        goal,
+	}
+
+	var lastInPeriod *domain.UserMetric
+	for i := range metrics {
+		if day, err := calc.ParseDate(metrics[i].Date); err == nil && !day.Before(from) && !day.After(to) {
+			lastInPeriod = &metrics[i]
+		}
+	}
+	if lastInPeriod != nil {
+		calc.AnnotateBMIForAge(lastInPeriod, user)
+		data.Category = lastInPeriod.BodyMetric
+	}
+
+	if goal != nil {
+		progress := calc.GoalProgress(*goal, calc.GoalSeries(goal.Type, metrics), now)
+		goal.Progress = &progress
+	}
+
+	in := calc.CompositionInput{Gender: user.Gender}
+	in.HeightCM = user.Height
+	if weights := calc.DailyWeights(metrics); len(weights) > 0 {
+		in.WeightKG = &weights[len(weights)-1].Value
+	}
+	for _, kind := range domain.MeasurementKinds() {
+		m, ok := latest[kind.Key]
+		if !ok {
+			continue
+		}
+		data.Measurements = append(data.Measurements, m)
+		value := m.Value
+		switch kind.Key {
+		case domain.MeasurementWaist:
+			in.WaistCM = &value
+		case domain.MeasurementHip:
+			in.HipCM = &value
+		case domain.MeasurementNeck:
+			in.NeckCM = &value
+		case domain.MeasurementBodyFat:
+			in.BodyFat = &value
+		}
+	}
+	data.Indicators = calc.BodyIndicators(in).Indicators
+
+	var buf bytes.Buffer
+	if err := report.Render(&buf, data); err != nil {
+		log.Printf("[report] user %d: %v", user.ID, err)
+		writeError(w, http.StatusInternalServerError, "failed to render report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="report-%d-%s.pdf"`, user.ID, to.Format(calc.DateLayout)))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+func between(days []calc.DailyValue, from, to time.Time) []calc.DailyValue {
+	days = calc.Since(days, from)
+	for i, d := range days {
+		if d.Date.After(to) {
+			return days[:i]
+		}
+	}
+	return days
+}
@@ -3,6 +3,9 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
 )
 
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -14,3 +17,20 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 func writeError(w http.ResponseWriter, status int, message string) {
 	writeJSON(w, status, map[string]string{"error": message})
 }
+
+func writeValidationError(w http.ResponseWriter, fields []domain.FieldError) {
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	writeJSON(w, http.StatusUnprocessableEntity, domain.ValidationErrorResponse{
+		Error:  "validation failed",
+		Fields: fields,
+	})
+}
+
+func writeConfirmationRequired(w http.ResponseWriter, warnings []domain.FieldError) {
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Field < warnings[j].Field })
+	writeJSON(w, http.StatusUnprocessableEntity, domain.ValidationErrorResponse{
+		Error:                "confirmation required",
+		Fields:               warnings,
+		ConfirmationRequired: true,
+	})
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/middleware"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+	"github.com/yusufkecer/body-metrics-backend/internal/service"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+	"github.com/yusufkecer/body-metrics-backend/internal/uuid"
+	"github.com/yusufkecer/body-metrics-backend/internal/validation"
+)
+
+const (
+	maxSyncChanges   = 500
+	defaultSyncLimit = 500
+	maxSyncLimit     = 2000
+)
+
+type SyncHandler struct {
+	repo  *repository.SyncRepository
+	goals *service.GoalTracker
+}
+
+func NewSyncHandler(repo *repository.SyncRepository, goals *service.GoalTracker) *SyncHandler {
+	return &SyncHandler{repo: repo, goals: goals}
+}
+
+// Sync applies the client's pending changes and returns everything that
+// changed on the server after the client's checkpoint, including the
+// client's own accepted changes.
+func (h *SyncHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	accountID, ok := r.Context().Value(middleware.AccountIDKey).(int64)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "invalid account context")
+		return
+	}
+
+	var req domain.SyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Checkpoint < 0 {
+		writeError(w, http.StatusBadRequest, "checkpoint must not be negative")
+		return
+	}
+	if len(req.Changes) > maxSyncChanges {
+		writeError(w, http.StatusBadRequest, "too many changes in one request")
+		return
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultSyncLimit
+	}
+	if limit < 1 || limit > maxSyncLimit {
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and 2000")
+		return
+	}
+
+	now := time.Now().UTC()
+	results := make([]domain.SyncResult, len(req.Changes))
+	var valid []domain.SyncChange
+	var validIndex []int
+	for i := range req.Changes {
+		c := &req.Changes[i]
+		if msg, fieldErrs := prepareSyncChange(c, now); msg != "" {
+			results[i] = domain.SyncResult{Entity: c.Entity, UUID: c.UUID, Status: domain.SyncRejected, Error: msg, Errors: fieldErrs}
+			continue
+		}
+		valid = append(valid, *c)
+		validIndex = append(validIndex, i)
+	}
+
+	if len(valid) > 0 {
+		// Sync has no confirmation round trip, so warnings do not block a
+		// change.
+		validate := func(user *domain.User, m *domain.UserMetric) []domain.FieldError {
+			errs, _ := validation.Metric(m, user, now)
+			return errs
+		}
+		var recomputed []int64
+		applied, err := h.repo.Apply(accountID, valid, validate, func(user *domain.User, all []domain.UserMetric) {
+			calc.DeriveHistory(all, user)
+			recomputed = append(recomputed, user.ID)
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to apply changes")
+			return
+		}
+		for _, userID := range recomputed {
+			h.goals.Check(userID)
+		}
+		for i, res := range applied {
+			results[validIndex[i]] = res
+		}
+	}
+
+	changes, checkpoint, more, err := h.repo.Changes(accountID, req.Checkpoint, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list changes")
+		return
+	}
+	if changes == nil {
+		changes = []domain.SyncChange{}
+	}
+
+	writeJSON(w, http.StatusOK, domain.SyncResponse{
+		Checkpoint: checkpoint,
+		HasMore:    more,
+		Results:    results,
+		Changes:    changes,
+	})
+}
+
+// prepareSyncChange validates an incoming change and decodes its data. It
+// returns a message describing why the change is rejected, or "", and the
+// field errors when the data itself failed validation.
+func prepareSyncChange(c *domain.SyncChange, now time.Time) (string, []domain.FieldError) {
+	id, ok := uuid.Normalize(c.UUID)
+	if !ok {
+		return "invalid uuid", nil
+	}
+	c.UUID = id
+
+	if c.Op != domain.SyncOpUpsert && c.Op != domain.SyncOpDelete {
+		return "op must be upsert or delete", nil
+	}
+	if c.ModifiedAt.IsZero() {
+		return "modified_at is required", nil
+	}
+	// A device clock running ahead must not win every future conflict.
+	c.ModifiedAt = c.ModifiedAt.UTC()
+	if c.ModifiedAt.After(now) {
+		c.ModifiedAt = now
+	}
+
+	switch c.Entity {
+	case domain.SyncEntityProfile:
+		if c.Op == domain.SyncOpDelete {
+			return "", nil
+		}
+		u, fieldErrs := syncProfile(c.Data, now)
+		if fieldErrs != nil {
+			return "invalid profile data", fieldErrs
+		}
+		c.Profile = u
+	case domain.SyncEntityMetric:
+		if c.Op == domain.SyncOpDelete {
+			return "", nil
+		}
+		profileID, ok := uuid.Normalize(c.ProfileUUID)
+		if !ok {
+			return "invalid profile_uuid", nil
+		}
+		c.ProfileUUID = profileID
+		var m domain.UserMetric
+		// The metric is validated once its profile is known, in Apply.
+		if err := json.Unmarshal(c.Data, &m); err != nil {
+			return "invalid metric data", nil
+		}
+		// Tags and confirmations are not part of the sync protocol.
+		m.Tags, m.Confirmed = nil, false
+		c.Metric = &m
+	default:
+		return "entity must be profile or metric", nil
+	}
+	return "", nil
+}
+
+// syncProfile validates profile data with the same rules as PATCH
+// /users/{id}. An upsert replaces the whole profile, so fields left out are
+// cleared and units default to kg and cm. Sync data is in SI units.
+func syncProfile(data json.RawMessage, now time.Time) (*domain.User, []domain.FieldError) {
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil || body == nil {
+		return nil, []domain.FieldError{{Field: "data", Code: domain.FieldInvalid, Message: "data must be a JSON object"}}
+	}
+	base := &domain.User{WeightUnit: units.Kilogram, HeightUnit: units.Centimeter}
+	patch, errs := parseUserPatch(body, base, units.Centimeter, now)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	u := &domain.User{WeightUnit: patch.WeightUnit, HeightUnit: patch.HeightUnit}
+	for column, v := range patch.Columns {
+		switch v := v.(type) {
+		case string:
+			switch column {
+			case "name":
+				u.Name = &v
+			case "surname":
+				u.Surname = &v
+			case "avatar":
+				u.Avatar = &v
+			case "birth_of_date":
+				u.BirthOfDate = &v
+			}
+		case int:
+			u.Gender = &v
+		case float64:
+			u.Height = &v
+		}
+	}
+	return u, nil
+}
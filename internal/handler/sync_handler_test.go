@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func TestSyncProfile(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	name, gender, height, birth := "Ada", domain.GenderFemale, 168.0, "1990-04-12"
+	url := "/api/v1/users/7/avatar"
+	served, err := json.Marshal(domain.User{
+		ID: 7, UUID: "9b2f6a9e-3c1d-4e5f-8a7b-1c2d3e4f5a6b", Version: 3,
+		Name: &name, Gender: &gender, Height: &height, BirthOfDate: &birth, AvatarURL: &url,
+		WeightUnit: "lb", HeightUnit: "in", CreatedAt: now, UpdatedAt: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("server output round-trips", func(t *testing.T) {
+		u, errs := syncProfile(served, now)
+		if errs != nil {
+			t.Fatalf("errors = %v", errs)
+		}
+		if u.Name == nil || *u.Name != name || u.Gender == nil || *u.Gender != gender ||
+			u.Height == nil || *u.Height != height || u.BirthOfDate == nil || *u.BirthOfDate != birth {
+			t.Errorf("profile = %+v", u)
+		}
+		if u.Surname != nil || u.Avatar != nil {
+			t.Errorf("omitted fields were not cleared: %+v", u)
+		}
+		if u.WeightUnit != "lb" || u.HeightUnit != "in" {
+			t.Errorf("units = %s/%s, want lb/in", u.WeightUnit, u.HeightUnit)
+		}
+	})
+
+	t.Run("units default to SI", func(t *testing.T) {
+		u, errs := syncProfile(json.RawMessage(`{"name":"Ada"}`), now)
+		if errs != nil {
+			t.Fatalf("errors = %v", errs)
+		}
+		if u.WeightUnit != "kg" || u.HeightUnit != "cm" {
+			t.Errorf("units = %s/%s, want kg/cm", u.WeightUnit, u.HeightUnit)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		data  string
+		field string
+		code  string
+	}{
+		{"not an object", `[1]`, "data", domain.FieldInvalid},
+		{"unknown gender", `{"gender":2}`, "gender", domain.FieldNotAllowed},
+		{"height out of range", `{"height":20}`, "height", domain.FieldOutOfRange},
+		{"birth date in future", `{"birthOfDate":"2030-01-01"}`, "birthOfDate", domain.FieldInFuture},
+		{"unsupported unit", `{"weight_unit":"oz"}`, "weight_unit", domain.FieldNotAllowed},
+		{"unknown field", `{"nickname":"A"}`, "nickname", domain.FieldUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := syncProfile(json.RawMessage(tt.data), now)
+			if len(errs) != 1 || errs[0].Field != tt.field || errs[0].Code != tt.code {
+				t.Errorf("errors = %v, want %s/%s", errs, tt.field, tt.code)
+			}
+		})
+	}
+}
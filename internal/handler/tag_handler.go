@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+	"github.com/yusufkecer/body-metrics-backend/internal/validation"
+)
+
+type TagHandler struct {
+	repo     *repository.TagRepository
+	userRepo *repository.UserRepository
+}
+
+func NewTagHandler(repo *repository.TagRepository, userRepo *repository.UserRepository) *TagHandler {
+	return &TagHandler{repo: repo, userRepo: userRepo}
+}
+
+// Create adds a tag to the profile's vocabulary. Tags used on a metric are
+// added automatically, so this is only needed to offer a tag up front.
+func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	var req domain.TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	name, ok := domain.NormalizeTag(req.Name)
+	if !ok {
+		writeValidationError(w, []domain.FieldError{validation.InvalidTag("name", req.Name)})
+		return
+	}
+
+	id, err := h.repo.Create(user.ID, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create tag")
+		return
+	}
+
+	created, err := h.repo.GetByIDAndUserID(id, user.ID)
+	if err != nil || created == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get created tag")
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *TagHandler) GetByUserID(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	tags, err := h.repo.GetByUserID(user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list tags")
+		return
+	}
+	if tags == nil {
+		tags = []domain.Tag{}
+	}
+
+	writeJSONWithETag(w, r, http.StatusOK, "", tags)
+}
+
+// Delete removes a tag from the vocabulary and from every metric using it.
+func (h *TagHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := ownedUser(w, r, h.userRepo)
+	if !ok {
+		return
+	}
+
+	tagID, err := strconv.ParseInt(mux.Vars(r)["tagId"], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid tag id")
+		return
+	}
+
+	deleted, err := h.repo.DeleteByIDAndUserID(tagID, user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete tag")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "tag not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+)
+
+// unitPreference resolves the units a request reads and writes: the
+// weight_unit/height_unit query parameters win over the profile preference.
+func unitPreference(r *http.Request, user *domain.User) (units.Preference, error) {
+	p := units.Default
+	if user != nil {
+		if user.WeightUnit != "" {
+			p.Weight = user.WeightUnit
+		}
+		if user.HeightUnit != "" {
+			p.Height = user.HeightUnit
+		}
+	}
+
+	q := r.URL.Query()
+	if v := q.Get("weight_unit"); v != "" {
+		p.Weight = v
+	}
+	if v := q.Get("height_unit"); v != "" {
+		p.Height = v
+	}
+	return p, p.Validate()
+}
+
+// bodyUnits applies the weight_unit/height_unit sent in a request body, which
+// win over the query parameters and the profile preference for that request.
+func bodyUnits(p units.Preference, weightUnit, heightUnit string) (units.Preference, []domain.FieldError) {
+	var errs []domain.FieldError
+	if weightUnit != "" {
+		if units.ValidWeightUnit(weightUnit) {
+			p.Weight = weightUnit
+		} else {
+			errs = append(errs, domain.FieldError{
+				Field:   "weight_unit",
+				Code:    domain.FieldNotAllowed,
+				Message: fmt.Sprintf("unsupported weight_unit %q", weightUnit),
+			})
+		}
+	}
+	if heightUnit != "" {
+		if units.ValidHeightUnit(heightUnit) {
+			p.Height = heightUnit
+		} else {
+			errs = append(errs, domain.FieldError{
+				Field:   "height_unit",
+				Code:    domain.FieldNotAllowed,
+				Message: fmt.Sprintf("unsupported height_unit %q", heightUnit),
+			})
+		}
+	}
+	return p, errs
+}
+
+// feetInchesToCM reads a height_ft_in input such as 5'10.5" into centimetres.
+// It is an alternative to height, so sending both is an error.
+func feetInchesToCM(raw string, heightSent bool) (float64, *domain.FieldError) {
+	if heightSent {
+		return 0, &domain.FieldError{Field: "height_ft_in", Code: domain.FieldInconsistent, Message: "send either height or height_ft_in"}
+	}
+	inches, err := units.ParseFeetInches(raw)
+	if err != nil {
+		return 0, &domain.FieldError{Field: "height_ft_in", Code: domain.FieldBadFormat, Message: err.Error()}
+	}
+	return units.HeightToCM(inches, units.Inch), nil
+}
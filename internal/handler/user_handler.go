@@ -2,21 +2,25 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/yusufkecer/body-metrics-backend/internal/domain"
 	"github.com/yusufkecer/body-metrics-backend/internal/middleware"
 	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
 )
 
 type UserHandler struct {
-	repo *repository.UserRepository
+	repo           *repository.UserRepository
+	requireIfMatch bool
 }
 
-func NewUserHandler(repo *repository.UserRepository) *UserHandler {
-	return &UserHandler{repo: repo}
+func NewUserHandler(repo *repository.UserRepository, requireIfMatch bool) *UserHandler {
+	return &UserHandler{repo: repo, requireIfMatch: requireIfMatch}
 }
 
 func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -42,13 +46,49 @@ func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.WeightUnit == "" {
+		user.WeightUnit = units.Kilogram
+	}
+	if user.HeightUnit == "" {
+		user.HeightUnit = units.Centimeter
+	}
+	pref, err := unitPreference(r, &user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := (units.Preference{Weight: user.WeightUnit, Height: user.HeightUnit}).Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if user.Height != nil {
+		height := units.HeightToCM(*user.Height, pref.Height)
+		user.Height = &height
+	}
+	if user.HeightFtIn != nil {
+		cm, fieldErr := feetInchesToCM(*user.HeightFtIn, user.Height != nil)
+		if fieldErr != nil {
+			writeValidationError(w, []domain.FieldError{*fieldErr})
+			return
+		}
+		user.Height, user.HeightFtIn = &cm, nil
+	}
+
+	// The check above gives a clear answer early; Create repeats it under a
+	// lock for requests that race past it.
 	id, err := h.repo.Create(accountID, &user)
+	if errors.Is(err, repository.ErrProfileExists) {
+		writeError(w, http.StatusConflict, "user profile already exists for this account")
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create user")
 		return
 	}
 
 	user.ID = id
+	user.SetAvatarURL()
+	units.UserFromSI(&user, pref)
 	writeJSON(w, http.StatusCreated, user)
 }
 
@@ -75,7 +115,15 @@ func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, user)
+	pref, err := unitPreference(r, user)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	etag := userETag(*user, pref)
+	units.UserFromSI(user, pref)
+
+	writeJSONWithETag(w, r, http.StatusOK, etag, user)
 }
 
 func (h *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -93,7 +141,15 @@ func (h *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	if users == nil {
 		users = []domain.User{}
 	}
-	writeJSON(w, http.StatusOK, users)
+	for i := range users {
+		pref, err := unitPreference(r, &users[i])
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		units.UserFromSI(&users[i], pref)
+	}
+	writeJSONWithETag(w, r, http.StatusOK, "", users)
 }
 
 func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -118,14 +174,45 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "user not found")
 		return
 	}
+	// Reject a bad unit override before it is used for the ETag or to read
+	// height.
+	currentPref, err := unitPreference(r, existingUser)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !checkIfMatch(w, r, userETag(*existingUser, currentPref), h.requireIfMatch) {
+		return
+	}
+	var version int64
+	if r.Header.Get("If-Match") != "" {
+		version = existingUser.Version
+	}
 
-	var fields map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	if err := h.repo.UpdateByIDAndAccountID(id, accountID, fields); err != nil {
+	patch, fieldErrs := parseUserPatch(body, existingUser, r.URL.Query().Get("height_unit"), time.Now().UTC())
+	if len(fieldErrs) > 0 {
+		writeValidationError(w, fieldErrs)
+		return
+	}
+	inputUser := *existingUser
+	inputUser.WeightUnit, inputUser.HeightUnit = patch.WeightUnit, patch.HeightUnit
+	pref, err := unitPreference(r, &inputUser)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.repo.UpdateByIDAndAccountID(id, accountID, version, patch.Columns); err != nil {
+		if errors.Is(err, repository.ErrVersionMismatch) {
+			writeError(w, http.StatusPreconditionFailed, "resource has been modified")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "failed to update user")
 		return
 	}
@@ -136,5 +223,7 @@ func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", userETag(*user, pref))
+	units.UserFromSI(user, pref)
 	writeJSON(w, http.StatusOK, user)
 }
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+	"github.com/yusufkecer/body-metrics-backend/internal/validation"
+)
+
+const (
+	maxNameLength   = 100
+	maxAvatarLength = 50
+)
+
+// responseOnlyUserFields are returned in a profile but never written through
+// it. They are ignored on input so a client can send a fetched profile back
+// with its changes.
+var responseOnlyUserFields = map[string]bool{
+	"id": true, "uuid": true, "version": true, "created_at": true, "updated_at": true, "avatar_url": true,
+}
+
+// userPatch is a validated partial profile update. Columns maps each changed
+// column to its new value; a nil value clears the column.
+type userPatch struct {
+	Columns    map[string]interface{}
+	WeightUnit string
+	HeightUnit string
+}
+
+// parseUserPatch checks every field of a PATCH /users/{id} body against the
+// current profile and returns all problems at once. Height is read in the
+// height unit that applies after the patch.
+func parseUserPatch(body map[string]json.RawMessage, current *domain.User, heightOverride string, now time.Time) (userPatch, []domain.FieldError) {
+	patch := userPatch{
+		Columns:    make(map[string]interface{}),
+		WeightUnit: current.WeightUnit,
+		HeightUnit: current.HeightUnit,
+	}
+	var errs []domain.FieldError
+	fail := func(field, code, format string, args ...interface{}) {
+		errs = append(errs, domain.FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Units come first because they decide how height is read.
+	for _, field := range []string{"weight_unit", "height_unit"} {
+		raw, ok := body[field]
+		if !ok {
+			continue
+		}
+		var unit *string
+		if err := json.Unmarshal(raw, &unit); err != nil {
+			fail(field, domain.FieldInvalid, "%s must be a string", field)
+			continue
+		}
+		if unit == nil {
+			fail(field, domain.FieldRequired, "%s cannot be cleared", field)
+			continue
+		}
+		valid := units.ValidWeightUnit(*unit)
+		if field == "height_unit" {
+			valid = units.ValidHeightUnit(*unit)
+		}
+		if !valid {
+			fail(field, domain.FieldNotAllowed, "unsupported %s %q", field, *unit)
+			continue
+		}
+		patch.Columns[field] = *unit
+		if field == "weight_unit" {
+			patch.WeightUnit = *unit
+		} else {
+			patch.HeightUnit = *unit
+		}
+	}
+
+	for field, raw := range body {
+		null := string(raw) == "null"
+		switch field {
+		case "weight_unit", "height_unit":
+		case "name", "surname":
+			var v string
+			if null {
+				patch.Columns[field] = nil
+			} else if err := json.Unmarshal(raw, &v); err != nil {
+				fail(field, domain.FieldInvalid, "%s must be a string", field)
+			} else if v = strings.TrimSpace(v); v == "" {
+				fail(field, domain.FieldTooShort, "%s must not be empty", field)
+			} else if utf8.RuneCountInString(v) > maxNameLength {
+				fail(field, domain.FieldTooLong, "%s must be at most %d characters", field, maxNameLength)
+			} else {
+				patch.Columns[field] = v
+			}
+		case "gender":
+			var v int
+			if null {
+				patch.Columns[field] = nil
+			} else if err := json.Unmarshal(raw, &v); err != nil {
+				fail(field, domain.FieldInvalid, "gender must be an integer")
+			} else if v != domain.GenderMale && v != domain.GenderFemale {
+				fail(field, domain.FieldNotAllowed, "gender must be %d (male) or %d (female)", domain.GenderMale, domain.GenderFemale)
+			} else {
+				patch.Columns[field] = v
+			}
+		case "avatar":
+			var v string
+			if null {
+				patch.Columns[field] = nil
+			} else if err := json.Unmarshal(raw, &v); err != nil {
+				fail(field, domain.FieldInvalid, "avatar must be a string")
+			} else if len(v) > maxAvatarLength {
+				fail(field, domain.FieldTooLong, "avatar must be at most %d characters", maxAvatarLength)
+			} else {
+				patch.Columns[field] = v
+			}
+		case "height":
+			var v float64
+			unit := patch.HeightUnit
+			if heightOverride != "" {
+				unit = heightOverride
+			}
+			if null {
+				patch.Columns[field] = nil
+			} else if err := json.Unmarshal(raw, &v); err != nil {
+				fail(field, domain.FieldInvalid, "height must be a number")
+			} else if cm := units.HeightToCM(v, unit); cm < validation.MinHeightCM || cm > validation.MaxHeightCM {
+				fail(field, domain.FieldOutOfRange, "height must be between %d and %d cm", validation.MinHeightCM, validation.MaxHeightCM)
+			} else {
+				patch.Columns[field] = cm
+			}
+		case "height_ft_in":
+			var v string
+			if null {
+				patch.Columns["height"] = nil
+			} else if err := json.Unmarshal(raw, &v); err != nil {
+				fail(field, domain.FieldInvalid, "height_ft_in must be a string")
+			} else if cm, fieldErr := feetInchesToCM(v, body["height"] != nil); fieldErr != nil {
+				errs = append(errs, *fieldErr)
+			} else if cm < validation.MinHeightCM || cm > validation.MaxHeightCM {
+				fail(field, domain.FieldOutOfRange, "height must be between %d and %d cm", validation.MinHeightCM, validation.MaxHeightCM)
+			} else {
+				patch.Columns["height"] = cm
+			}
+		case "birthOfDate", "birth_of_date":
+			var v string
+			if null {
+				patch.Columns["birth_of_date"] = nil
+			} else if err := json.Unmarshal(raw, &v); err != nil {
+				fail(field, domain.FieldInvalid, "%s must be a string", field)
+			} else if t, err := calc.ParseDate(v); err != nil {
+				fail(field, domain.FieldBadFormat, "%s is not a recognised date", field)
+			} else if !t.Before(now) {
+				fail(field, domain.FieldInFuture, "%s must be in the past", field)
+			} else if t.Year() < 1900 {
+				fail(field, domain.FieldOutOfRange, "%s must not be before 1900", field)
+			} else {
+				patch.Columns["birth_of_date"] = strings.TrimSpace(v)
+			}
+		default:
+			if !responseOnlyUserFields[field] {
+				fail(field, domain.FieldUnknown, "unknown field %s", field)
+			}
+		}
+	}
+	return patch, errs
+}
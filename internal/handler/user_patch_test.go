@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func TestParseUserPatchResponseFields(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	current := &domain.User{WeightUnit: "kg", HeightUnit: "cm"}
+
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(`{
+		"id": 7, "uuid": "9b2f6a9e-3c1d-4e5f-8a7b-1c2d3e4f5a6b", "version": 3,
+		"created_at": "2024-01-01T00:00:00Z", "updated_at": "2024-01-01T00:00:00Z",
+		"avatar_url": "/api/v1/users/7/avatar", "name": "Ada"
+	}`), &body); err != nil {
+		t.Fatal(err)
+	}
+	patch, errs := parseUserPatch(body, current, "", now)
+	if errs != nil {
+		t.Fatalf("errors = %v", errs)
+	}
+	if len(patch.Columns) != 1 || patch.Columns["name"] != "Ada" {
+		t.Errorf("columns = %v, want only name", patch.Columns)
+	}
+
+	_, errs = parseUserPatch(map[string]json.RawMessage{"nickname": json.RawMessage(`"A"`)}, current, "", now)
+	if len(errs) != 1 || errs[0].Code != domain.FieldUnknown {
+		t.Errorf("errors = %v, want one %s", errs, domain.FieldUnknown)
+	}
+}
@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+)
+
+const (
+	hkBodyMass     = "HKQuantityTypeIdentifierBodyMass"
+	hkHeight       = "HKQuantityTypeIdentifierHeight"
+	hkBodyFat      = "HKQuantityTypeIdentifierBodyFatPercentage"
+	hkLeanMass     = "HKQuantityTypeIdentifierLeanBodyMass"
+	hkDateLayout   = "2006-01-02 15:04:05 -0700"
+	appleExportXML = "export.xml"
+)
+
+type AppleHealth struct{}
+
+func (AppleHealth) Name() string {
+	return domain.ImportSourceAppleHealth
+}
+
+// Open accepts either a bare export.xml or the zipped Health export.
+func (AppleHealth) Open(filePath string) (io.ReadCloser, int64, error) {
+	return openMaybeZip(filePath, func(name string) bool {
+		return path.Base(name) == appleExportXML
+	})
+}
+
+// Parse streams a Health export and keeps body mass, height, body fat and lean
+// mass samples. Report rows refer to the sample's position among matched
+// records.
+func (AppleHealth) Parse(r io.Reader, now time.Time) ([]Record, []domain.ImportRow, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+
+	days := make(map[time.Time]*Record)
+	var heights []datedHeight
+	var invalid []domain.ImportRow
+	n := 0
+
+	for {
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid health export: %w", err)
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok || el.Name.Local != "Record" {
+			continue
+		}
+
+		attrs := make(map[string]string, 4)
+		for _, a := range el.Attr {
+			switch a.Name.Local {
+			case "type", "unit", "value", "startDate":
+				attrs[a.Name.Local] = a.Value
+			}
+		}
+		kind := attrs["type"]
+		if kind != hkBodyMass && kind != hkHeight && kind != hkBodyFat && kind != hkLeanMass {
+			continue
+		}
+		n++
+
+		at, err := time.Parse(hkDateLayout, attrs["startDate"])
+		if err != nil {
+			invalid = append(invalid, Invalid(n, "invalid startDate %q", attrs["startDate"]))
+			continue
+		}
+		day := dayOf(at)
+
+		value, err := strconv.ParseFloat(attrs["value"], 64)
+		if err != nil {
+			invalid = append(invalid, Invalid(n, "invalid value %q", attrs["value"]))
+			continue
+		}
+
+		if kind == hkHeight {
+			cm, err := appleHeightCM(value, attrs["unit"])
+			if err != nil {
+				invalid = append(invalid, Invalid(n, "%v", err))
+				continue
+			}
+			heights = append(heights, datedHeight{date: day, cm: cm})
+			continue
+		}
+
+		rec := days[day]
+		if rec == nil {
+			rec = &Record{Date: day}
+			days[day] = rec
+		}
+		rec.Row = n
+
+		switch kind {
+		case hkBodyMass, hkLeanMass:
+			kg, err := appleMassKG(value, attrs["unit"])
+			if err != nil {
+				invalid = append(invalid, Invalid(n, "%v", err))
+				continue
+			}
+			if kind == hkBodyMass {
+				rec.WeightKG = &kg
+			} else {
+				rec.LeanMassKG = &kg
+			}
+		case hkBodyFat:
+			pct := value * 100
+			rec.BodyFat = &pct
+		}
+	}
+
+	records := make([]Record, 0, len(days))
+	for _, rec := range days {
+		records = append(records, *rec)
+	}
+	valid, rejected := finalizeDays(records, heights, now)
+	return valid, append(invalid, rejected...), nil
+}
+
+func appleMassKG(value float64, unit string) (float64, error) {
+	switch unit {
+	case "kg":
+		return value, nil
+	case "g":
+		return value / 1000, nil
+	case "lb":
+		return units.WeightToKG(value, units.Pound), nil
+	case "st":
+		return units.WeightToKG(value, units.Stone), nil
+	}
+	return 0, fmt.Errorf("unsupported mass unit %q", unit)
+}
+
+func appleHeightCM(value float64, unit string) (int, error) {
+	switch unit {
+	case "cm":
+		return int(units.HeightToCM(value, units.Centimeter)), nil
+	case "m":
+		return int(units.HeightToCM(value*100, units.Centimeter)), nil
+	case "in":
+		return int(units.HeightToCM(value, units.Inch)), nil
+	case "ft":
+		return int(units.HeightToCM(value*12, units.Inch)), nil
+	}
+	return 0, fmt.Errorf("unsupported height unit %q", unit)
+}
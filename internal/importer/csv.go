@@ -0,0 +1,191 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+)
+
+const MaxCSVRows = 20000
+
+type CSVOptions struct {
+	DateColumn   string
+	WeightColumn string
+	HeightColumn string
+	// DateFormat accepts YYYY, MM, DD, HH, mm and ss tokens (e.g. DD.MM.YYYY).
+	// Empty means auto-detect the common ISO and European layouts.
+	DateFormat string
+	WeightUnit string
+	HeightUnit string
+	Delimiter  rune
+}
+
+var dateTokens = strings.NewReplacer(
+	"YYYY", "2006", "MM", "01", "DD", "02", "HH", "15", "mm", "04", "ss", "05",
+)
+
+func (o CSVOptions) parseDate(s string) (time.Time, error) {
+	if o.DateFormat == "" {
+		return calc.ParseDate(s)
+	}
+	t, err := time.Parse(dateTokens.Replace(o.DateFormat), strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date %q does not match %s", s, o.DateFormat)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+}
+
+// resolveColumn maps a column given by header name (case-insensitive) or by
+// 1-based position to its index. Missing optional columns resolve to -1.
+func resolveColumn(header []string, column string, required bool) (int, error) {
+	if column == "" {
+		return -1, nil
+	}
+	if n, err := strconv.Atoi(column); err == nil {
+		if n < 1 || n > len(header) {
+			return -1, fmt.Errorf("column %d does not exist", n)
+		}
+		return n - 1, nil
+	}
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), column) {
+			return i, nil
+		}
+	}
+	if required {
+		return -1, fmt.Errorf("column %q not found in header", column)
+	}
+	return -1, nil
+}
+
+// CSV is the generic spreadsheet source; the column mapping comes from the
+// upload request.
+type CSV struct {
+	Options CSVOptions
+}
+
+func (CSV) Name() string {
+	return domain.ImportSourceCSV
+}
+
+func (c CSV) Parse(r io.Reader, now time.Time) ([]Record, []domain.ImportRow, error) {
+	return ParseCSV(r, c.Options, now)
+}
+
+// ParseCSV reads a header row followed by weigh-ins. Rows that fail validation
+// are reported and skipped; the error is only set when the file itself is
+// unusable.
+func ParseCSV(r io.Reader, opts CSVOptions, now time.Time) ([]Record, []domain.ImportRow, error) {
+	reader := csv.NewReader(r)
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("csv is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	dateIdx, err := resolveColumn(header, opts.DateColumn, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	weightIdx, err := resolveColumn(header, opts.WeightColumn, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	heightIdx, err := resolveColumn(header, opts.HeightColumn, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []Record
+	var invalid []domain.ImportRow
+	for count := 1; ; count++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if count > MaxCSVRows {
+			return nil, nil, fmt.Errorf("csv has more than %d rows", MaxCSVRows)
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			invalid = append(invalid, Invalid(parseErr.StartLine, "malformed row: %v", parseErr.Err))
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+		row, _ := reader.FieldPos(0)
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+
+		field := func(idx int) string {
+			if idx < 0 || idx >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[idx])
+		}
+
+		date, err := opts.parseDate(field(dateIdx))
+		if err != nil {
+			invalid = append(invalid, Invalid(row, "%v", err))
+			continue
+		}
+		rec := Record{Row: row, Date: date}
+
+		if raw := field(weightIdx); raw != "" {
+			v, err := parseNumber(raw)
+			if err != nil {
+				invalid = append(invalid, Invalid(row, "invalid weight %q", raw))
+				continue
+			}
+			kg := units.WeightToKG(v, opts.WeightUnit)
+			rec.WeightKG = &kg
+		}
+		if raw := field(heightIdx); raw != "" {
+			v, err := parseNumber(raw)
+			if err != nil {
+				invalid = append(invalid, Invalid(row, "invalid height %q", raw))
+				continue
+			}
+			cm := int(units.HeightToCM(v, opts.HeightUnit))
+			rec.HeightCM = &cm
+		}
+
+		if rec.WeightKG == nil {
+			invalid = append(invalid, Invalid(row, "weight is required"))
+			continue
+		}
+		if err := validate(rec, now); err != nil {
+			res := Invalid(row, "%v", err)
+			res.Date = date.Format(calc.DateLayout)
+			invalid = append(invalid, res)
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, invalid, nil
+}
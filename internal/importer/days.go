@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+type datedHeight struct {
+	date time.Time
+	cm   int
+}
+
+// finalizeDays orders per-day records, pairs each day with the most recent
+// height recorded on or before it and validates the result.
+func finalizeDays(records []Record, heights []datedHeight, now time.Time) ([]Record, []domain.ImportRow) {
+	sort.SliceStable(heights, func(i, j int) bool { return heights[i].date.Before(heights[j].date) })
+	sort.Slice(records, func(i, j int) bool { return records[i].Date.Before(records[j].Date) })
+
+	var invalid []domain.ImportRow
+	valid := records[:0]
+	h := -1
+	for _, rec := range records {
+		for h+1 < len(heights) && !heights[h+1].date.After(rec.Date) {
+			h++
+		}
+		if h >= 0 && rec.HeightCM == nil {
+			cm := heights[h].cm
+			rec.HeightCM = &cm
+		}
+		if rec.WeightKG == nil && rec.BodyFat == nil && rec.LeanMassKG == nil {
+			continue
+		}
+		if err := validate(rec, now); err != nil {
+			invalid = append(invalid, Invalid(rec.Row, "%v", err))
+			continue
+		}
+		valid = append(valid, rec)
+	}
+	return valid, invalid
+}
+
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// openMaybeZip opens a plain file as-is, or concatenates the entries of a zip
+// archive accepted by match. The size is the uncompressed total.
+func openMaybeZip(filePath string, match func(name string) bool) (io.ReadCloser, int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		f.Close()
+		return nil, 0, errors.New("file is too short")
+	}
+	if !bytes.Equal(magic, []byte("PK\x03\x04")) {
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		return f, info.Size(), nil
+	}
+	f.Close()
+
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid zip archive: %w", err)
+	}
+	var entries []*zip.File
+	var total int64
+	for _, entry := range zr.File {
+		if !entry.FileInfo().IsDir() && match(entry.Name) {
+			entries = append(entries, entry)
+			total += int64(entry.UncompressedSize64)
+		}
+	}
+	if len(entries) == 0 {
+		zr.Close()
+		return nil, 0, errors.New("zip does not contain a supported export file")
+	}
+	return &zipStream{archive: zr, entries: entries}, total, nil
+}
+
+// zipStream reads the selected archive entries back to back, separated by a
+// newline so line- and token-based parsers see distinct documents.
+type zipStream struct {
+	archive *zip.ReadCloser
+	entries []*zip.File
+	current io.ReadCloser
+	pending []byte
+}
+
+func (z *zipStream) Read(p []byte) (int, error) {
+	for {
+		if len(z.pending) > 0 {
+			n := copy(p, z.pending)
+			z.pending = z.pending[n:]
+			return n, nil
+		}
+		if z.current == nil {
+			if len(z.entries) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := z.entries[0].Open()
+			if err != nil {
+				return 0, err
+			}
+			z.current, z.entries = rc, z.entries[1:]
+		}
+		n, err := z.current.Read(p)
+		if err == io.EOF {
+			z.current.Close()
+			z.current = nil
+			z.pending = []byte("\n")
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (z *zipStream) Close() error {
+	if z.current != nil {
+		z.current.Close()
+	}
+	return z.archive.Close()
+}
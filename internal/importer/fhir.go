@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/fhir"
+)
+
+// FHIR reads body weight and height Observations from an R4 Bundle of any
+// type. BMI Observations are ignored because BMI is derived on import.
+type FHIR struct{}
+
+func (FHIR) Name() string {
+	return domain.ImportSourceFHIR
+}
+
+// Parse reports rows by 1-based Bundle entry index.
+func (FHIR) Parse(r io.Reader, now time.Time) ([]Record, []domain.ImportRow, error) {
+	var bundle fhir.Bundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, nil, fmt.Errorf("invalid fhir bundle: %w", err)
+	}
+	if bundle.ResourceType != "Bundle" {
+		return nil, nil, errors.New("resourceType must be Bundle")
+	}
+
+	days := make(map[time.Time]*Record)
+	var heights []datedHeight
+	var invalid []domain.ImportRow
+
+	for i, entry := range bundle.Entry {
+		n := i + 1
+		obs := entry.Resource
+		if obs.ResourceType != "Observation" {
+			continue
+		}
+		isWeight := obs.Code.HasCode(fhir.LOINCBodyWeight) || obs.Code.HasCode(fhir.LOINCBodyWeightMeasured)
+		isHeight := obs.Code.HasCode(fhir.LOINCBodyHeight)
+		if !isWeight && !isHeight {
+			continue
+		}
+		if obs.Status == "entered-in-error" || obs.Status == "cancelled" {
+			continue
+		}
+
+		at, err := calc.ParseDate(obs.EffectiveTime())
+		if err != nil {
+			invalid = append(invalid, Invalid(n, "invalid effective date %q", obs.EffectiveTime()))
+			continue
+		}
+		if obs.ValueQuantity == nil || obs.ValueQuantity.Value == nil {
+			invalid = append(invalid, Invalid(n, "valueQuantity is required"))
+			continue
+		}
+		value := *obs.ValueQuantity.Value
+		unit := obs.ValueQuantity.Code
+		if unit == "" {
+			unit = obs.ValueQuantity.Unit
+		}
+
+		if isHeight {
+			cm, err := appleHeightCM(value, ucumUnits[unit])
+			if err != nil {
+				invalid = append(invalid, Invalid(n, "unsupported height unit %q", unit))
+				continue
+			}
+			heights = append(heights, datedHeight{date: at, cm: cm})
+			continue
+		}
+
+		kg, err := appleMassKG(value, ucumUnits[unit])
+		if err != nil {
+			invalid = append(invalid, Invalid(n, "unsupported weight unit %q", unit))
+			continue
+		}
+		rec := days[at]
+		if rec == nil {
+			rec = &Record{Date: at}
+			days[at] = rec
+		}
+		rec.Row = n
+		rec.WeightKG = &kg
+	}
+
+	records := make([]Record, 0, len(days))
+	for _, rec := range days {
+		records = append(records, *rec)
+	}
+	valid, rejected := finalizeDays(records, heights, now)
+	return valid, append(invalid, rejected...), nil
+}
+
+// ucumUnits maps the UCUM codes FHIR uses onto the unit names shared with the
+// Apple Health parser.
+var ucumUnits = map[string]string{
+	"kg":      "kg",
+	"g":       "g",
+	"[lb_av]": "lb",
+	"lb":      "lb",
+	"cm":      "cm",
+	"m":       "m",
+	"[in_i]":  "in",
+	"in":      "in",
+	"[ft_i]":  "ft",
+}
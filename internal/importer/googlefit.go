@@ -0,0 +1,125 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+)
+
+const (
+	fitWeight  = "com.google.weight"
+	fitHeight  = "com.google.height"
+	fitBodyFat = "com.google.body.fat.percentage"
+)
+
+// GoogleFit reads the "All Data" JSON files of a Google Takeout Fit export.
+type GoogleFit struct{}
+
+type fitDocument struct {
+	DataSource string         `json:"Data Source"`
+	DataPoints []fitDataPoint `json:"Data Points"`
+}
+
+type fitDataPoint struct {
+	DataTypeName   string `json:"dataTypeName"`
+	StartTimeNanos int64  `json:"startTimeNanos"`
+	FitValue       []struct {
+		Value struct {
+			FpVal *float64 `json:"fpVal"`
+		} `json:"value"`
+	} `json:"fitValue"`
+}
+
+func (GoogleFit) Name() string {
+	return domain.ImportSourceGoogleFit
+}
+
+// Open accepts a single data file or the Takeout zip, in which case only the
+// weight, height and body fat files are read.
+func (GoogleFit) Open(filePath string) (io.ReadCloser, int64, error) {
+	return openMaybeZip(filePath, func(name string) bool {
+		base := path.Base(name)
+		if path.Ext(base) != ".json" {
+			return false
+		}
+		return strings.Contains(base, fitWeight) || strings.Contains(base, fitHeight) || strings.Contains(base, fitBodyFat)
+	})
+}
+
+// Parse decodes one or more concatenated Fit documents. Points are collapsed
+// to the last value per day; report rows refer to the point's position among
+// matched data points.
+func (GoogleFit) Parse(r io.Reader, now time.Time) ([]Record, []domain.ImportRow, error) {
+	decoder := json.NewDecoder(r)
+
+	days := make(map[time.Time]*Record)
+	var heights []datedHeight
+	var invalid []domain.ImportRow
+	n, docs := 0, 0
+
+	for {
+		var doc fitDocument
+		err := decoder.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid fit export: %w", err)
+		}
+		docs++
+
+		for _, point := range doc.DataPoints {
+			kind := point.DataTypeName
+			if kind != fitWeight && kind != fitHeight && kind != fitBodyFat {
+				continue
+			}
+			n++
+
+			if point.StartTimeNanos <= 0 {
+				invalid = append(invalid, Invalid(n, "missing startTimeNanos"))
+				continue
+			}
+			day := dayOf(time.Unix(0, point.StartTimeNanos).UTC())
+
+			if len(point.FitValue) == 0 || point.FitValue[0].Value.FpVal == nil {
+				invalid = append(invalid, Invalid(n, "missing fpVal"))
+				continue
+			}
+			value := *point.FitValue[0].Value.FpVal
+
+			if kind == fitHeight {
+				heights = append(heights, datedHeight{date: day, cm: int(units.HeightToCM(value*100, units.Centimeter))})
+				continue
+			}
+
+			rec := days[day]
+			if rec == nil {
+				rec = &Record{Date: day}
+				days[day] = rec
+			}
+			rec.Row = n
+			if kind == fitWeight {
+				rec.WeightKG = &value
+			} else {
+				rec.BodyFat = &value
+			}
+		}
+	}
+	if docs == 0 {
+		return nil, nil, errors.New("fit export is empty")
+	}
+
+	records := make([]Record, 0, len(days))
+	for _, rec := range days {
+		records = append(records, *rec)
+	}
+	valid, rejected := finalizeDays(records, heights, now)
+	return valid, append(invalid, rejected...), nil
+}
@@ -0,0 +1,177 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/validation"
+)
+
+// Source parses one export format into records. Rows that fail validation are
+// returned as invalid report rows; the error is reserved for unreadable input.
+type Source interface {
+	Name() string
+	Parse(r io.Reader, now time.Time) ([]Record, []domain.ImportRow, error)
+}
+
+// Opener is implemented by sources whose uploads may be archives. Open
+// returns the stream to parse and its uncompressed size.
+type Opener interface {
+	Open(path string) (io.ReadCloser, int64, error)
+}
+
+var fileSources = map[string]Source{
+	domain.ImportSourceAppleHealth: AppleHealth{},
+	domain.ImportSourceGoogleFit:   GoogleFit{},
+	domain.ImportSourceWithings:    Withings{},
+}
+
+// LookupFileSource returns the source registered for background file imports.
+func LookupFileSource(name string) (Source, bool) {
+	src, ok := fileSources[name]
+	return src, ok
+}
+
+func Open(src Source, path string) (io.ReadCloser, int64, error) {
+	if opener, ok := src.(Opener); ok {
+		return opener.Open(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+type Record struct {
+	Row        int
+	Date       time.Time
+	WeightKG   *float64
+	HeightCM   *int
+	BodyFat    *float64
+	LeanMassKG *float64
+}
+
+func Invalid(row int, format string, args ...interface{}) domain.ImportRow {
+	return domain.ImportRow{Row: row, Status: domain.ImportRowInvalid, Error: fmt.Sprintf(format, args...)}
+}
+
+// validate applies the metric endpoint's range, date and format rules to the
+// weight and height of a record. Warnings that the API asks a client to
+// confirm do not reject a row; outlier detection flags those after import.
+func validate(rec Record, now time.Time) error {
+	if rec.WeightKG == nil && rec.BodyFat == nil && rec.LeanMassKG == nil {
+		return fmt.Errorf("record has no values")
+	}
+	if rec.Date.After(now.Add(validation.FutureDateTolerance)) {
+		return fmt.Errorf("date is in the future")
+	}
+	if rec.WeightKG != nil || rec.HeightCM != nil {
+		m := domain.UserMetric{Date: rec.Date.Format(calc.DateLayout), Weight: rec.WeightKG}
+		if rec.HeightCM != nil {
+			m.Height = float64(*rec.HeightCM)
+		}
+		if errs := validation.MetricValues(&m, nil, now); len(errs) > 0 {
+			return errors.New(errs[0].Message)
+		}
+	}
+	if err := validateMeasurement(domain.MeasurementBodyFat, rec.BodyFat); err != nil {
+		return err
+	}
+	return validateMeasurement(domain.MeasurementLeanMass, rec.LeanMassKG)
+}
+
+func validateMeasurement(kind string, value *float64) error {
+	if value == nil {
+		return nil
+	}
+	k, _ := domain.LookupMeasurementKind(kind)
+	if *value < k.Min || *value > k.Max {
+		return fmt.Errorf("%s %.2f %s is out of range", kind, *value, k.Unit)
+	}
+	return nil
+}
+
+// createdAtLayout matches how the app serialises timestamps, so imported
+// history sorts alongside entries recorded on the device.
+const createdAtLayout = "2006-01-02T15:04:05.000"
+
+// ToMetrics converts the records that carry a weight into metric entries.
+// The returned index maps each metric back to its record.
+func ToMetrics(records []Record) ([]domain.UserMetric, []int) {
+	var metrics []domain.UserMetric
+	var index []int
+	for i, rec := range records {
+		if rec.WeightKG == nil {
+			continue
+		}
+		createdAt := rec.Date.Format(createdAtLayout)
+		m := domain.UserMetric{
+			Date:      rec.Date.Format(calc.DateLayout),
+			Weight:    rec.WeightKG,
+			CreatedAt: &createdAt,
+		}
+		if rec.HeightCM != nil {
+			m.Height = float64(*rec.HeightCM)
+		}
+		metrics = append(metrics, m)
+		index = append(index, i)
+	}
+	return metrics, index
+}
+
+// ToMeasurements converts body fat and lean mass values into measurements.
+// The returned index maps each measurement back to its record.
+func ToMeasurements(records []Record) ([]domain.Measurement, []int) {
+	var out []domain.Measurement
+	var index []int
+	add := func(i int, kind string, value *float64) {
+		if value == nil {
+			return
+		}
+		k, _ := domain.LookupMeasurementKind(kind)
+		out = append(out, domain.Measurement{
+			Kind:  kind,
+			Value: calc.Round(*value, k.Precision),
+			Unit:  k.Unit,
+			Date:  records[i].Date.Format(calc.DateLayout),
+		})
+		index = append(index, i)
+	}
+	for i, rec := range records {
+		add(i, domain.MeasurementBodyFat, rec.BodyFat)
+		add(i, domain.MeasurementLeanMass, rec.LeanMassKG)
+	}
+	return out, index
+}
+
+// BuildReport marks a record as a duplicate only when none of its values
+// were new; records without a weight count as imported measurements.
+func BuildReport(records []Record, invalid []domain.ImportRow, duplicates []bool) domain.ImportReport {
+	rows := append([]domain.ImportRow(nil), invalid...)
+	for i, rec := range records {
+		status := domain.ImportRowImported
+		if i < len(duplicates) && duplicates[i] {
+			status = domain.ImportRowDuplicate
+		}
+		rows = append(rows, domain.ImportRow{Row: rec.Row, Date: rec.Date.Format(calc.DateLayout), Status: status})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Row < rows[j].Row })
+
+	report := domain.ImportReport{Rows: make([]domain.ImportRow, 0, len(rows))}
+	for _, row := range rows {
+		report.Add(row)
+	}
+	return report
+}
@@ -0,0 +1,151 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	healthExport = `<?xml version="1.0" encoding="UTF-8"?>
+<HealthData locale="en_US">
+ <Record type="HKQuantityTypeIdentifierHeight" unit="cm" value="180" startDate="2024-03-01 08:00:00 +0000"/>
+ <Record type="HKQuantityTypeIdentifierBodyMass" unit="kg" value="81.2" startDate="2024-04-01 07:30:00 +0000"/>
+ <Record type="HKQuantityTypeIdentifierBodyMass" unit="lb" value="178" startDate="2024-04-02 07:30:00 +0000"/>
+ <Record type="HKQuantityTypeIdentifierStepCount" unit="count" value="4000" startDate="2024-04-02 09:00:00 +0000"/>
+ <Record type="HKQuantityTypeIdentifierBodyMass" unit="kg" value="80" startDate="2030-01-01 07:30:00 +0000"/>
+</HealthData>`
+
+	fitExport = `{"Data Source":"derived:com.google.weight","Data Points":[
+ {"dataTypeName":"com.google.weight","startTimeNanos":1711958400000000000,"fitValue":[{"value":{"fpVal":81.2}}]},
+ {"dataTypeName":"com.google.body.fat.percentage","startTimeNanos":1711958400000000000,"fitValue":[{"value":{"fpVal":21.5}}]},
+ {"dataTypeName":"com.google.weight","startTimeNanos":0,"fitValue":[{"value":{"fpVal":80}}]}
+]}`
+
+	withingsExport = `Date,Weight (kg),Fat mass (kg),Bone mass (kg),Comments
+"2024-04-01 07:30:00",80,16,,
+"2024-04-02 07:30:00",heavy,,,
+`
+
+	fhirBundle = `{"resourceType":"Bundle","type":"collection","entry":[
+ {"resource":{"resourceType":"Observation","status":"final",
+  "code":{"coding":[{"system":"http://loinc.org","code":"8302-2"}]},
+  "effectiveDateTime":"2024-03-01","valueQuantity":{"value":180,"code":"cm"}}},
+ {"resource":{"resourceType":"Observation","status":"final",
+  "code":{"coding":[{"system":"http://loinc.org","code":"29463-7"}]},
+  "effectiveDateTime":"2024-04-01","valueQuantity":{"value":81.2,"code":"kg"}}},
+ {"resource":{"resourceType":"Observation","status":"final",
+  "code":{"coding":[{"system":"http://loinc.org","code":"29463-7"}]},
+  "effectiveDateTime":"2024-04-02","valueQuantity":{"value":700,"code":"kg"}}}
+]}`
+)
+
+func TestSourcesParse(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	csvOptions := CSVOptions{DateColumn: "date", WeightColumn: "weight", HeightColumn: "height", WeightUnit: "kg", HeightUnit: "cm"}
+
+	tests := []struct {
+		name        string
+		src         Source
+		input       string
+		wantErr     string
+		wantDays    []string
+		wantWeights []float64
+		wantHeight  int
+		wantBodyFat float64
+		wantInvalid int
+	}{
+		{
+			name: "csv", src: CSV{Options: csvOptions},
+			input:    "date,weight,height\n2024-04-01,81.2,180\n2024-04-02,\"80,5\",\n2024-04-03,700,\n2024-04-04,abc,\n",
+			wantDays: []string{"2024-04-01", "2024-04-02"}, wantWeights: []float64{81.2, 80.5}, wantHeight: 180, wantInvalid: 2,
+		},
+		{
+			name: "csv without the weight column", src: CSV{Options: csvOptions},
+			input: "date,kg\n2024-04-01,81.2\n", wantErr: `column "weight" not found`,
+		},
+		{
+			name: "apple health", src: AppleHealth{}, input: healthExport,
+			wantDays: []string{"2024-04-01", "2024-04-02"}, wantWeights: []float64{81.2, 80.74}, wantHeight: 180, wantInvalid: 1,
+		},
+		{
+			name: "apple health truncated", src: AppleHealth{}, input: healthExport[:200], wantErr: "invalid health export",
+		},
+		{
+			name: "google fit", src: GoogleFit{}, input: fitExport,
+			wantDays: []string{"2024-04-01"}, wantWeights: []float64{81.2}, wantBodyFat: 21.5, wantInvalid: 1,
+		},
+		{
+			name: "google fit not json", src: GoogleFit{}, input: "Data Points: none", wantErr: "invalid fit export",
+		},
+		{
+			name: "withings", src: Withings{}, input: withingsExport,
+			wantDays: []string{"2024-04-01"}, wantWeights: []float64{80}, wantBodyFat: 20, wantInvalid: 1,
+		},
+		{
+			name: "withings without header", src: Withings{}, input: "2024-04-01 07:30:00,80\n", wantErr: "no header row",
+		},
+		{
+			name: "fhir", src: FHIR{}, input: fhirBundle,
+			wantDays: []string{"2024-04-01"}, wantWeights: []float64{81.2}, wantHeight: 180, wantInvalid: 1,
+		},
+		{
+			name: "fhir patient", src: FHIR{}, input: `{"resourceType":"Patient"}`, wantErr: "resourceType must be Bundle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, invalid, err := tt.src.Parse(strings.NewReader(tt.input), now)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(invalid) != tt.wantInvalid {
+				t.Errorf("invalid = %v, want %d rows", invalid, tt.wantInvalid)
+			}
+			if len(records) != len(tt.wantDays) {
+				t.Fatalf("records = %+v, want %d", records, len(tt.wantDays))
+			}
+			for i, rec := range records {
+				if day := rec.Date.Format(calc.DateLayout); day != tt.wantDays[i] {
+					t.Errorf("record %d date = %s, want %s", i, day, tt.wantDays[i])
+				}
+				if rec.WeightKG == nil || calc.Round(*rec.WeightKG, 2) != tt.wantWeights[i] {
+					t.Errorf("record %d weight = %v, want %v", i, rec.WeightKG, tt.wantWeights[i])
+				}
+			}
+			first := records[0]
+			if tt.wantHeight != 0 && (first.HeightCM == nil || *first.HeightCM != tt.wantHeight) {
+				t.Errorf("height = %v, want %d", first.HeightCM, tt.wantHeight)
+			}
+			if tt.wantBodyFat != 0 && (first.BodyFat == nil || calc.Round(*first.BodyFat, 1) != tt.wantBodyFat) {
+				t.Errorf("body fat = %v, want %v", first.BodyFat, tt.wantBodyFat)
+			}
+		})
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 4, d, 0, 0, 0, 0, time.UTC) }
+	records := []Record{{Row: 1, Date: day(1)}, {Row: 3, Date: day(2)}}
+	invalid := []domain.ImportRow{Invalid(2, "invalid weight %q", "abc")}
+
+	report := BuildReport(records, invalid, []bool{false, true})
+	if report.Total != 3 || report.Imported != 1 || report.Duplicates != 1 || report.Invalid != 1 {
+		t.Errorf("report counts = %+v", report)
+	}
+	want := []string{domain.ImportRowImported, domain.ImportRowInvalid, domain.ImportRowDuplicate}
+	for i, row := range report.Rows {
+		if row.Row != i+1 || row.Status != want[i] {
+			t.Errorf("row %d = %+v, want row %d %s", i, row, i+1, want[i])
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+)
+
+const withingsDateLayout = "2006-01-02 15:04:05"
+
+var withingsUnit = regexp.MustCompile(`\(([^)]+)\)\s*$`)
+
+// Withings reads the weight.csv and height.csv files of a Withings data
+// export. Each file starts with its own header row, so both can be uploaded
+// separately or together in the export zip.
+type Withings struct{}
+
+type withingsColumns struct {
+	date, weight, fatMass, fatRatio, height int
+	weightUnit, fatUnit, heightUnit         string
+}
+
+func (Withings) Name() string {
+	return domain.ImportSourceWithings
+}
+
+func (Withings) Open(filePath string) (io.ReadCloser, int64, error) {
+	return openMaybeZip(filePath, func(name string) bool {
+		base := strings.ToLower(path.Base(name))
+		return base == "weight.csv" || base == "height.csv"
+	})
+}
+
+// Parse keeps weight, fat and height rows. Fat mass is converted to a body fat
+// ratio using the weight on the same row. Report rows are line numbers within
+// the concatenated input.
+func (Withings) Parse(r io.Reader, now time.Time) ([]Record, []domain.ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	days := make(map[time.Time]*Record)
+	var heights []datedHeight
+	var invalid []domain.ImportRow
+	var cols *withingsColumns
+
+	for count := 1; ; count++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if count > MaxCSVRows {
+			return nil, nil, fmt.Errorf("csv has more than %d rows", MaxCSVRows)
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			invalid = append(invalid, Invalid(parseErr.StartLine, "malformed row: %v", parseErr.Err))
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+		row, _ := reader.FieldPos(0)
+		if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+
+		first := strings.TrimPrefix(strings.TrimSpace(fields[0]), "\ufeff")
+		if strings.EqualFold(first, "Date") {
+			cols = withingsHeader(fields)
+			if cols == nil {
+				return nil, nil, fmt.Errorf("line %d: unrecognised withings header", row)
+			}
+			continue
+		}
+		if cols == nil {
+			return nil, nil, errors.New("withings export has no header row")
+		}
+
+		field := func(idx int) string {
+			if idx < 0 || idx >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[idx])
+		}
+
+		at, err := time.Parse(withingsDateLayout, field(cols.date))
+		if err != nil {
+			invalid = append(invalid, Invalid(row, "invalid date %q", field(cols.date)))
+			continue
+		}
+		day := dayOf(at)
+
+		if raw := field(cols.height); raw != "" {
+			v, err := parseNumber(raw)
+			if err != nil {
+				invalid = append(invalid, Invalid(row, "invalid height %q", raw))
+				continue
+			}
+			cm, err := appleHeightCM(v, cols.heightUnit)
+			if err != nil {
+				invalid = append(invalid, Invalid(row, "%v", err))
+				continue
+			}
+			heights = append(heights, datedHeight{date: day, cm: cm})
+			continue
+		}
+
+		raw := field(cols.weight)
+		if raw == "" {
+			continue
+		}
+		weight, err := parseNumber(raw)
+		if err != nil {
+			invalid = append(invalid, Invalid(row, "invalid weight %q", raw))
+			continue
+		}
+		kg, err := withingsMassKG(weight, cols.weightUnit)
+		if err != nil {
+			invalid = append(invalid, Invalid(row, "%v", err))
+			continue
+		}
+
+		rec := days[day]
+		if rec == nil {
+			rec = &Record{Date: day}
+			days[day] = rec
+		}
+		rec.Row = row
+		rec.WeightKG = &kg
+		rec.BodyFat = nil
+
+		if raw := field(cols.fatRatio); raw != "" {
+			if pct, err := parseNumber(raw); err == nil {
+				rec.BodyFat = &pct
+			}
+		} else if raw := field(cols.fatMass); raw != "" && kg > 0 {
+			if fat, err := parseNumber(raw); err == nil {
+				if fatKG, err := withingsMassKG(fat, cols.fatUnit); err == nil {
+					pct := fatKG / kg * 100
+					rec.BodyFat = &pct
+				}
+			}
+		}
+	}
+
+	records := make([]Record, 0, len(days))
+	for _, rec := range days {
+		records = append(records, *rec)
+	}
+	valid, rejected := finalizeDays(records, heights, now)
+	return valid, append(invalid, rejected...), nil
+}
+
+// withingsHeader maps the export's column titles, e.g. "Weight (kg)" or
+// "Fat mass (lb)". The unit is taken from the parentheses.
+func withingsHeader(header []string) *withingsColumns {
+	cols := &withingsColumns{date: -1, weight: -1, fatMass: -1, fatRatio: -1, height: -1}
+	for i, h := range header {
+		h = strings.TrimPrefix(strings.TrimSpace(h), "\ufeff")
+		name := strings.ToLower(strings.TrimSpace(withingsUnit.ReplaceAllString(h, "")))
+		unit := ""
+		if m := withingsUnit.FindStringSubmatch(h); m != nil {
+			unit = strings.ToLower(m[1])
+		}
+		switch name {
+		case "date":
+			cols.date = i
+		case "weight":
+			cols.weight, cols.weightUnit = i, unit
+		case "fat mass":
+			cols.fatMass, cols.fatUnit = i, unit
+		case "fat ratio":
+			cols.fatRatio = i
+		case "height", "value":
+			if unit == "m" || unit == "cm" || unit == "in" || unit == "ft" {
+				cols.height, cols.heightUnit = i, unit
+			}
+		}
+	}
+	if cols.date < 0 || (cols.weight < 0 && cols.height < 0) {
+		return nil
+	}
+	return cols
+}
+
+func withingsMassKG(value float64, unit string) (float64, error) {
+	switch unit {
+	case "", "kg":
+		return value, nil
+	case "lb", "lbs":
+		return units.WeightToKG(value, units.Pound), nil
+	case "st":
+		return units.WeightToKG(value, units.Stone), nil
+	}
+	return 0, fmt.Errorf("unsupported mass unit %q", unit)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// BodyLimit caps request bodies at defaultLimit bytes. Routes named in
+// overrides get their own limit instead, since http.MaxBytesReader can only
+// be applied once per request.
+func BodyLimit(defaultLimit int64, overrides map[string]int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := defaultLimit
+			if route := mux.CurrentRoute(r); route != nil {
+				if override, ok := overrides[route.GetName()]; ok {
+					limit = override
+				}
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency replays the stored response when a POST is retried with the
+// same Idempotency-Key. Keys are scoped to the authenticated account, so the
+// middleware must run after AuthMiddleware. Routes named in skip (large
+// streaming uploads) are passed through untouched.
+//
+// The unauthenticated /auth routes have no account to scope a key to and all
+// clients share one API key, so they are not covered. They are safe to retry
+// without it: a repeated register fails on the unique email and a repeated
+// reset-password fails because reset tokens are single use.
+type Idempotency struct {
+	repo idempotencyStore
+	ttl  time.Duration
+	skip map[string]bool
+}
+
+// idempotencyStore is the part of IdempotencyRepository the middleware uses,
+// so tests can run it without a database.
+type idempotencyStore interface {
+	Reserve(accountID int64, key, requestHash string, expiresAt time.Time) (*domain.IdempotencyKey, bool, error)
+	Complete(id int64, statusCode int, contentType, location, etag string, body []byte) error
+	Delete(id int64) error
+	DeleteExpired() (int64, error)
+}
+
+func NewIdempotency(repo *repository.IdempotencyRepository, ttl time.Duration, skip ...string) *Idempotency {
+	idem := &Idempotency{repo: repo, ttl: ttl, skip: make(map[string]bool, len(skip))}
+	for _, name := range skip {
+		idem.skip[name] = true
+	}
+	go idem.cleanup()
+	return idem
+}
+
+func (i *Idempotency) cleanup() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, err := i.repo.DeleteExpired(); err != nil {
+			log.Printf("[idempotency] %v", err)
+		}
+	}
+}
+
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if route := mux.CurrentRoute(r); route != nil && i.skip[route.GetName()] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		accountID, ok := r.Context().Value(AccountIDKey).(int64)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeJSONError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				return
+			}
+			writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.New()
+		io.WriteString(sum, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+		sum.Write(body)
+		hash := hex.EncodeToString(sum.Sum(nil))
+
+		record, created, err := i.repo.Reserve(accountID, key, hash, time.Now().Add(i.ttl))
+		if err != nil {
+			log.Printf("[idempotency] %v", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to process Idempotency-Key")
+			return
+		}
+		if !created {
+			switch {
+			case record.RequestHash != hash:
+				writeJSONError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+			case record.StatusCode == nil:
+				writeJSONError(w, http.StatusConflict, "a request with this Idempotency-Key is still in progress")
+			default:
+				for name, value := range map[string]string{
+					"Content-Type": record.ContentType,
+					"Location":     record.Location,
+					"ETag":         record.ETag,
+				} {
+					if value != "" {
+						w.Header().Set(name, value)
+					}
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(*record.StatusCode)
+				w.Write(record.ResponseBody)
+			}
+			return
+		}
+
+		// A panicking handler would otherwise leave the key reserved, and every
+		// retry would get 409 until it expires.
+		defer func() {
+			if p := recover(); p != nil {
+				i.release(record.ID)
+				panic(p)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// Only successes are stored. A rejected request changed nothing, so
+		// the client may fix it and retry under the same key, for example by
+		// resending a metric with "confirm": true after confirmation_required.
+		if rec.status < http.StatusOK || rec.status >= http.StatusMultipleChoices {
+			i.release(record.ID)
+			return
+		}
+		h := w.Header()
+		if err := i.repo.Complete(record.ID, rec.status, h.Get("Content-Type"), h.Get("Location"), h.Get("ETag"), rec.body.Bytes()); err != nil {
+			log.Printf("[idempotency] %v", err)
+		}
+	})
+}
+
+func (i *Idempotency) release(id int64) {
+	if err := i.repo.Delete(id); err != nil {
+		log.Printf("[idempotency] %v", err)
+	}
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(`{"error":"` + message + `"}`))
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+type fakeIdempotencyStore struct {
+	mu     sync.Mutex
+	nextID int64
+	keys   map[string]*domain.IdempotencyKey
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{keys: make(map[string]*domain.IdempotencyKey)}
+}
+
+func (f *fakeIdempotencyStore) Reserve(accountID int64, key, requestHash string, expiresAt time.Time) (*domain.IdempotencyKey, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if existing, ok := f.keys[key]; ok {
+		copied := *existing
+		return &copied, false, nil
+	}
+	f.nextID++
+	k := &domain.IdempotencyKey{ID: f.nextID, AccountID: accountID, Key: key, RequestHash: requestHash, ExpiresAt: expiresAt}
+	f.keys[key] = k
+	return k, true, nil
+}
+
+func (f *fakeIdempotencyStore) Complete(id int64, statusCode int, contentType, location, etag string, body []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range f.keys {
+		if k.ID == id {
+			k.StatusCode, k.ContentType, k.Location, k.ETag = &statusCode, contentType, location, etag
+			k.ResponseBody = append([]byte(nil), body...)
+		}
+	}
+	return nil
+}
+
+func (f *fakeIdempotencyStore) Delete(id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, k := range f.keys {
+		if k.ID == id {
+			delete(f.keys, key)
+		}
+	}
+	return nil
+}
+
+func (f *fakeIdempotencyStore) DeleteExpired() (int64, error) { return 0, nil }
+
+// TestIdempotencyConfirmRetry follows a client that resends a metric with
+// "confirm": true under the same key after the first attempt asked for
+// confirmation.
+func TestIdempotencyConfirmRetry(t *testing.T) {
+	created := 0
+	create := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if !bytes.Contains(body, []byte(`"confirm":true`)) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"error":"confirmation required","confirmation_required":true}`))
+			return
+		}
+		created++
+		w.Header().Set("Location", "/api/v1/users/7/metrics/42")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":42}`))
+	})
+	idem := &Idempotency{repo: newFakeIdempotencyStore(), ttl: time.Hour}
+	handler := idem.Middleware(create)
+
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/7/metrics", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "weigh-in-1")
+		req = req.WithContext(context.WithValue(req.Context(), AccountIDKey, int64(1)))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	steps := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantReplay bool
+	}{
+		{"unconfirmed", `{"weight":310}`, http.StatusUnprocessableEntity, false},
+		{"confirmed", `{"weight":310,"confirm":true}`, http.StatusCreated, false},
+		{"confirmed retry", `{"weight":310,"confirm":true}`, http.StatusCreated, true},
+		{"different request", `{"weight":311,"confirm":true}`, http.StatusUnprocessableEntity, false},
+	}
+	for _, step := range steps {
+		rec := send(step.body)
+		if rec.Code != step.wantStatus {
+			t.Fatalf("%s: status = %d, want %d; body = %s", step.name, rec.Code, step.wantStatus, rec.Body)
+		}
+		if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != step.wantReplay {
+			t.Errorf("%s: replayed = %v, want %v", step.name, replayed, step.wantReplay)
+		}
+		if step.wantReplay && (rec.Header().Get("Location") != "/api/v1/users/7/metrics/42" || rec.Body.String() != `{"id":42}`) {
+			t.Errorf("%s: replayed Location %q, body %s", step.name, rec.Header().Get("Location"), rec.Body)
+		}
+	}
+	if created != 1 {
+		t.Errorf("metric created %d times, want once", created)
+	}
+}
@@ -39,8 +39,9 @@ func CORSMiddleware(allowedOrigins string) func(http.Handler) http.Handler {
 			} else if len(origins) > 0 && origins[0] == "*" {
 				w.Header().Set("Access-Control-Allow-Origin", "*")
 			}
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Idempotency-Key, If-Match, If-None-Match")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed")
 
 			if r.Method == http.MethodOptions {
 				w.WriteHeader(http.StatusNoContent)
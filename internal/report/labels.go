@@ -0,0 +1,189 @@
+package report
+
+import "strings"
+
+const (
+	LangTR = "tr"
+	LangEN = "en"
+)
+
+var labels = map[string]map[string]string{
+	LangTR: {
+		"title":                    "İlerleme Raporu",
+		"generated":                "Oluşturulma",
+		"period":                   "Dönem",
+		"profile":                  "Profil",
+		"name":                     "Ad Soyad",
+		"gender":                   "Cinsiyet",
+		"age":                      "Yaş",
+		"height":                   "Boy",
+		"male":                     "Erkek",
+		"female":                   "Kadın",
+		"chart":                    "Kilo ve VKİ",
+		"weight":                   "Kilo",
+		"bmi":                      "VKİ",
+		"no_data":                  "Bu dönemde ölçüm yok",
+		"summary":                  "Özet",
+		"entries":                  "Ölçüm sayısı",
+		"start_weight":             "Başlangıç kilosu",
+		"current_weight":           "Güncel kilo",
+		"change":                   "Değişim",
+		"min_weight":               "En düşük",
+		"max_weight":               "En yüksek",
+		"avg_weight":               "Ortalama",
+		"start_bmi":                "Başlangıç VKİ",
+		"current_bmi":              "Güncel VKİ",
+		"category":                 "Sınıf",
+		"goal":                     "Hedef",
+		"no_goal":                  "Aktif hedef yok",
+		"target":                   "Hedef değer",
+		"target_date":              "Hedef tarihi",
+		"current":                  "Güncel",
+		"completed":                "Tamamlanan",
+		"required_rate":            "Gereken haftalık",
+		"recent_rate":              "Son haftalık",
+		"on_track":                 "Planda",
+		"yes":                      "Evet",
+		"no":                       "Hayır",
+		"composition":              "Vücut Kompozisyonu",
+		"no_composition":           "Vücut ölçümü yok",
+		"indicators":               "Göstergeler",
+		"per_week":                 "/hafta",
+		"disclaimer":               "Bu rapor bilgilendirme amaçlıdır ve tıbbi tanı yerine geçmez.",
+		"underweight":              "Zayıf",
+		"normal":                   "Normal",
+		"overweight":               "Fazla kilolu",
+		"obese":                    "Obez",
+		"body_fat":                 "Vücut yağı",
+		"muscle_mass":              "Kas kütlesi",
+		"lean_mass":                "Yağsız kütle",
+		"waist":                    "Bel",
+		"hip":                      "Kalça",
+		"neck":                     "Boyun",
+		"chest":                    "Göğüs",
+		"arm":                      "Kol",
+		"resting_heart_rate":       "Dinlenik nabız",
+		"blood_pressure_systolic":  "Büyük tansiyon",
+		"blood_pressure_diastolic": "Küçük tansiyon",
+		"waist_to_hip":             "Bel/kalça oranı",
+		"waist_to_height":          "Bel/boy oranı",
+		"navy_body_fat":            "Navy yağ tahmini",
+		"ffmi":                     "FFMI",
+		"low":                      "Düşük",
+		"moderate":                 "Orta",
+		"high":                     "Yüksek",
+		"healthy":                  "Sağlıklı",
+		"increased":                "Artmış",
+		"essential":                "Temel",
+		"athletic":                 "Atletik",
+		"fitness":                  "Fit",
+		"average":                  "Ortalama",
+		"below_average":            "Ortalama altı",
+		"above_average":            "Ortalama üstü",
+		"excellent":                "Çok iyi",
+		"superior":                 "Üstün",
+		"very_high":                "Çok yüksek",
+	},
+	LangEN: {
+		"title":                    "Progress Report",
+		"generated":                "Generated",
+		"period":                   "Period",
+		"profile":                  "Profile",
+		"name":                     "Name",
+		"gender":                   "Gender",
+		"age":                      "Age",
+		"height":                   "Height",
+		"male":                     "Male",
+		"female":                   "Female",
+		"chart":                    "Weight and BMI",
+		"weight":                   "Weight",
+		"bmi":                      "BMI",
+		"no_data":                  "No entries in this period",
+		"summary":                  "Summary",
+		"entries":                  "Entries",
+		"start_weight":             "Start weight",
+		"current_weight":           "Current weight",
+		"change":                   "Change",
+		"min_weight":               "Lowest",
+		"max_weight":               "Highest",
+		"avg_weight":               "Average",
+		"start_bmi":                "Start BMI",
+		"current_bmi":              "Current BMI",
+		"category":                 "Category",
+		"goal":                     "Goal",
+		"no_goal":                  "No active goal",
+		"target":                   "Target",
+		"target_date":              "Target date",
+		"current":                  "Current",
+		"completed":                "Completed",
+		"required_rate":            "Required weekly",
+		"recent_rate":              "Recent weekly",
+		"on_track":                 "On track",
+		"yes":                      "Yes",
+		"no":                       "No",
+		"composition":              "Body Composition",
+		"no_composition":           "No body measurements",
+		"indicators":               "Indicators",
+		"per_week":                 "/week",
+		"disclaimer":               "This report is for information only and is not a medical diagnosis.",
+		"underweight":              "Underweight",
+		"normal":                   "Normal",
+		"overweight":               "Overweight",
+		"obese":                    "Obese",
+		"body_fat":                 "Body fat",
+		"muscle_mass":              "Muscle mass",
+		"lean_mass":                "Lean mass",
+		"waist":                    "Waist",
+		"hip":                      "Hip",
+		"neck":                     "Neck",
+		"chest":                    "Chest",
+		"arm":                      "Arm",
+		"resting_heart_rate":       "Resting heart rate",
+		"blood_pressure_systolic":  "Systolic pressure",
+		"blood_pressure_diastolic": "Diastolic pressure",
+		"waist_to_hip":             "Waist-to-hip ratio",
+		"waist_to_height":          "Waist-to-height ratio",
+		"navy_body_fat":            "Navy body fat",
+		"ffmi":                     "FFMI",
+		"low":                      "Low",
+		"moderate":                 "Moderate",
+		"high":                     "High",
+		"healthy":                  "Healthy",
+		"increased":                "Increased",
+		"essential":                "Essential",
+		"athletic":                 "Athletic",
+		"fitness":                  "Fitness",
+		"average":                  "Average",
+		"below_average":            "Below average",
+		"above_average":            "Above average",
+		"excellent":                "Excellent",
+		"superior":                 "Superior",
+		"very_high":                "Very high",
+	},
+}
+
+// Language picks tr or en from an explicit value or an Accept-Language
+// header, defaulting to Turkish like the rest of our user-facing text.
+func Language(explicit, acceptLanguage string) string {
+	if _, ok := labels[explicit]; ok {
+		return explicit
+	}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		tag = strings.SplitN(tag, "-", 2)[0]
+		if _, ok := labels[tag]; ok {
+			return tag
+		}
+	}
+	return LangTR
+}
+
+func translator(lang string) func(string) string {
+	table := labels[lang]
+	return func(key string) string {
+		if v, ok := table[key]; ok {
+			return v
+		}
+		return strings.ReplaceAll(key, "_", " ")
+	}
+}
@@ -0,0 +1,439 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/units"
+)
+
+// DejaVu Sans Condensed (Bitstream Vera licence) covers the Turkish letters
+// that the PDF core fonts cannot encode.
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	fontBold []byte
+)
+
+const (
+	fontFamily = "dejavu"
+	pageWidth  = 210.0
+	margin     = 15.0
+	contentW   = pageWidth - 2*margin
+)
+
+// Data is everything the one-page report shows. Series and goal values are in
+// SI units; they are converted to Units when rendered.
+type Data struct {
+	User         domain.User
+	Lang         string
+	Units        units.Preference
+	From         time.Time
+	To           time.Time
+	GeneratedAt  time.Time
+	Weights      []calc.DailyValue
+	BMIs         []calc.DailyValue
+	Category     *string
+	Goal         *domain.Goal
+	Measurements []domain.Measurement
+	Indicators   []domain.BodyIndicator
+}
+
+type renderer struct {
+	pdf  *fpdf.Fpdf
+	data Data
+	t    func(string) string
+}
+
+func Render(w io.Writer, data Data) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBold)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, margin)
+	pdf.SetTitle("BodyMetrics", true)
+	pdf.SetCreator("BodyMetrics", true)
+	pdf.SetCreationDate(data.GeneratedAt)
+	pdf.AddPage()
+
+	r := &renderer{pdf: pdf, data: data, t: translator(data.Lang)}
+	r.header()
+	r.profile()
+	r.chart()
+	r.summary()
+	r.goal()
+	r.composition()
+	r.footer()
+
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return pdf.Output(w)
+}
+
+func (r *renderer) number(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	if r.data.Lang == LangTR {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
+
+// percent follows the locale's sign placement: %12 in Turkish, 12% in English.
+func (r *renderer) percent(v float64, decimals int) string {
+	if r.data.Lang == LangTR {
+		return "%" + r.number(v, decimals)
+	}
+	return r.number(v, decimals) + "%"
+}
+
+func (r *renderer) date(t time.Time) string {
+	if r.data.Lang == LangTR {
+		return t.Format("02.01.2006")
+	}
+	return t.Format(calc.DateLayout)
+}
+
+func (r *renderer) weight(kg float64) string {
+	return r.number(units.WeightFromKG(kg, r.data.Units.Weight), 1) + " " + r.data.Units.Weight
+}
+
+func (r *renderer) section(title string) {
+	r.pdf.Ln(3)
+	r.pdf.SetFont(fontFamily, "B", 11)
+	r.pdf.SetTextColor(98, 0, 238)
+	r.pdf.CellFormat(contentW, 6, title, "B", 1, "L", false, 0, "")
+	r.pdf.SetTextColor(0, 0, 0)
+	r.pdf.Ln(1)
+}
+
+// pairs lays out label/value rows in columns of the given count.
+func (r *renderer) pairs(rows [][2]string, columns int) {
+	colW := contentW / float64(columns)
+	for i, row := range rows {
+		r.pdf.SetFont(fontFamily, "", 8.5)
+		r.pdf.SetTextColor(110, 110, 110)
+		r.pdf.CellFormat(colW*0.5, 5, row[0], "", 0, "L", false, 0, "")
+		r.pdf.SetFont(fontFamily, "B", 8.5)
+		r.pdf.SetTextColor(0, 0, 0)
+		ln := 0
+		if (i+1)%columns == 0 || i == len(rows)-1 {
+			ln = 1
+		}
+		r.pdf.CellFormat(colW*0.5, 5, row[1], "", ln, "L", false, 0, "")
+	}
+}
+
+func (r *renderer) header() {
+	r.pdf.SetFont(fontFamily, "B", 16)
+	r.pdf.CellFormat(contentW*0.6, 8, "BodyMetrics · "+r.t("title"), "", 0, "L", false, 0, "")
+	r.pdf.SetFont(fontFamily, "", 8.5)
+	r.pdf.SetTextColor(110, 110, 110)
+	r.pdf.CellFormat(contentW*0.4, 4, r.t("generated")+": "+r.date(r.data.GeneratedAt), "", 2, "R", false, 0, "")
+	r.pdf.CellFormat(contentW*0.4, 4, r.t("period")+": "+r.date(r.data.From)+" – "+r.date(r.data.To), "", 1, "R", false, 0, "")
+	r.pdf.SetTextColor(0, 0, 0)
+}
+
+func (r *renderer) profile() {
+	u := r.data.User
+	r.section(r.t("profile"))
+
+	var name []string
+	if u.Name != nil {
+		name = append(name, *u.Name)
+	}
+	if u.Surname != nil {
+		name = append(name, *u.Surname)
+	}
+	rows := [][2]string{{r.t("name"), dash(strings.Join(name, " "))}}
+
+	gender := "-"
+	if domain.KnownGender(u.Gender) {
+		gender = r.t("male")
+		if *u.Gender == domain.GenderFemale {
+			gender = r.t("female")
+		}
+	}
+	rows = append(rows, [2]string{r.t("gender"), gender})
+
+	age := "-"
+	if u.BirthOfDate != nil {
+		if birth, err := calc.ParseDate(*u.BirthOfDate); err == nil {
+			age = strconv.Itoa(calc.AgeYears(birth, r.data.GeneratedAt))
+		}
+	}
+	rows = append(rows, [2]string{r.t("age"), age})
+
+	height := "-"
+	if u.Height != nil {
+		height = strconv.FormatFloat(units.HeightFromCM(*u.Height, r.data.Units.Height), 'f', -1, 64) + " " + r.data.Units.Height
+	}
+	rows = append(rows, [2]string{r.t("height"), height})
+
+	r.pairs(rows, 2)
+}
+
+func (r *renderer) chart() {
+	r.section(r.t("chart"))
+
+	pdf := r.pdf
+	x0, y0 := margin+12, pdf.GetY()+2
+	w, h := contentW-24, 62.0
+	pdf.SetY(y0 + h + 9)
+
+	if len(r.data.Weights) == 0 && len(r.data.BMIs) == 0 {
+		pdf.SetDrawColor(200, 200, 200)
+		pdf.Rect(x0, y0, w, h, "D")
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.SetXY(x0, y0+h/2-3)
+		pdf.CellFormat(w, 6, r.t("no_data"), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetY(y0 + h + 9)
+		return
+	}
+
+	span := r.data.To.Sub(r.data.From).Hours() / 24
+	if span < 1 {
+		span = 1
+	}
+	xAt := func(t time.Time) float64 {
+		return x0 + w*t.Sub(r.data.From).Hours()/24/span
+	}
+
+	weights := make([]calc.DailyValue, len(r.data.Weights))
+	for i, d := range r.data.Weights {
+		weights[i] = calc.DailyValue{Date: d.Date, Value: units.WeightFromKG(d.Value, r.data.Units.Weight)}
+	}
+	wMin, wMax := niceRange(weights)
+	bMin, bMax := niceRange(r.data.BMIs)
+
+	// Grid and axis labels: weight on the left, BMI on the right.
+	const ticks = 4
+	pdf.SetLineWidth(0.1)
+	pdf.SetFont(fontFamily, "", 7)
+	for i := 0; i <= ticks; i++ {
+		y := y0 + h - h*float64(i)/ticks
+		pdf.SetDrawColor(225, 225, 225)
+		pdf.Line(x0, y, x0+w, y)
+		if len(weights) > 0 {
+			pdf.SetTextColor(33, 150, 243)
+			pdf.SetXY(x0-12, y-2)
+			pdf.CellFormat(11, 4, r.number(wMin+(wMax-wMin)*float64(i)/ticks, 1), "", 0, "R", false, 0, "")
+		}
+		if len(r.data.BMIs) > 0 {
+			pdf.SetTextColor(255, 152, 0)
+			pdf.SetXY(x0+w+1, y-2)
+			pdf.CellFormat(11, 4, r.number(bMin+(bMax-bMin)*float64(i)/ticks, 1), "", 0, "L", false, 0, "")
+		}
+	}
+	pdf.SetTextColor(110, 110, 110)
+	for i := 0; i <= ticks; i++ {
+		at := r.data.From.Add(time.Duration(float64(i) / ticks * span * float64(24*time.Hour)))
+		pdf.SetXY(xAt(at)-12, y0+h+1)
+		pdf.CellFormat(24, 4, r.date(at), "", 0, "C", false, 0, "")
+	}
+	pdf.SetDrawColor(160, 160, 160)
+	pdf.Rect(x0, y0, w, h, "D")
+
+	plot := func(series []calc.DailyValue, lo, hi float64, red, green, blue int) {
+		yAt := func(v float64) float64 { return y0 + h - h*(v-lo)/(hi-lo) }
+		pdf.SetDrawColor(red, green, blue)
+		pdf.SetFillColor(red, green, blue)
+		pdf.SetLineWidth(0.5)
+		for i, d := range series {
+			x, y := xAt(d.Date), yAt(d.Value)
+			if i > 0 {
+				pdf.Line(xAt(series[i-1].Date), yAt(series[i-1].Value), x, y)
+			}
+			if len(series) <= 60 {
+				pdf.Circle(x, y, 0.7, "F")
+			}
+		}
+	}
+	plot(r.data.BMIs, bMin, bMax, 255, 152, 0)
+	plot(weights, wMin, wMax, 33, 150, 243)
+
+	legend := func(x float64, label string, red, green, blue int) {
+		pdf.SetFillColor(red, green, blue)
+		pdf.Rect(x, y0+h+6.2, 3, 1.6, "F")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetXY(x+4, y0+h+5)
+		pdf.CellFormat(30, 4, label, "", 0, "L", false, 0, "")
+	}
+	legend(x0, r.t("weight")+" ("+r.data.Units.Weight+")", 33, 150, 243)
+	legend(x0+40, r.t("bmi"), 255, 152, 0)
+
+	pdf.SetLineWidth(0.2)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(margin, y0+h+10)
+}
+
+// niceRange pads the series extent so lines don't touch the frame.
+func niceRange(series []calc.DailyValue) (float64, float64) {
+	if len(series) == 0 {
+		return 0, 1
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, d := range series {
+		lo = math.Min(lo, d.Value)
+		hi = math.Max(hi, d.Value)
+	}
+	pad := (hi - lo) * 0.1
+	if pad < 0.5 {
+		pad = 0.5
+	}
+	return math.Floor(lo - pad), math.Ceil(hi + pad)
+}
+
+func (r *renderer) summary() {
+	r.section(r.t("summary"))
+	weights := r.data.Weights
+	rows := [][2]string{{r.t("entries"), strconv.Itoa(len(weights))}}
+	if len(weights) > 0 {
+		first, last := weights[0].Value, weights[len(weights)-1].Value
+		lo, hi, sum := math.Inf(1), math.Inf(-1), 0.0
+		for _, d := range weights {
+			lo, hi, sum = math.Min(lo, d.Value), math.Max(hi, d.Value), sum+d.Value
+		}
+		change := units.WeightFromKG(last, r.data.Units.Weight) - units.WeightFromKG(first, r.data.Units.Weight)
+		sign := ""
+		if change > 0 {
+			sign = "+"
+		}
+		rows = append(rows,
+			[2]string{r.t("start_weight"), r.weight(first)},
+			[2]string{r.t("current_weight"), r.weight(last)},
+			[2]string{r.t("change"), sign + r.number(change, 1) + " " + r.data.Units.Weight},
+			[2]string{r.t("min_weight"), r.weight(lo)},
+			[2]string{r.t("max_weight"), r.weight(hi)},
+			[2]string{r.t("avg_weight"), r.weight(sum / float64(len(weights)))},
+		)
+	}
+	if bmis := r.data.BMIs; len(bmis) > 0 {
+		rows = append(rows,
+			[2]string{r.t("start_bmi"), r.number(bmis[0].Value, 1)},
+			[2]string{r.t("current_bmi"), r.number(bmis[len(bmis)-1].Value, 1)},
+		)
+	}
+	if r.data.Category != nil {
+		rows = append(rows, [2]string{r.t("category"), r.t(*r.data.Category)})
+	}
+	r.pairs(rows, 3)
+}
+
+func (r *renderer) goal() {
+	r.section(r.t("goal"))
+	g := r.data.Goal
+	if g == nil || g.Progress == nil {
+		r.note(r.t("no_goal"))
+		return
+	}
+	p := g.Progress
+
+	value := func(v float64) string {
+		if g.Type == domain.GoalTypeWeight {
+			return r.weight(v)
+		}
+		return r.number(v, 1)
+	}
+	rate := func(v *float64) string {
+		if v == nil {
+			return "-"
+		}
+		if g.Type == domain.GoalTypeWeight {
+			return r.number(units.WeightFromKG(*v, r.data.Units.Weight), 2) + " " + r.data.Units.Weight + r.t("per_week")
+		}
+		return r.number(*v, 2) + r.t("per_week")
+	}
+	yesNo := r.t("no")
+	if p.OnTrack || p.Reached {
+		yesNo = r.t("yes")
+	}
+	targetDate := g.TargetDate
+	if t, err := calc.ParseDate(g.TargetDate); err == nil {
+		targetDate = r.date(t)
+	}
+
+	r.pairs([][2]string{
+		{r.t("target"), r.t(g.Type) + " " + value(g.TargetValue)},
+		{r.t("target_date"), targetDate},
+		{r.t("current"), value(p.CurrentValue)},
+		{r.t("completed"), r.percent(p.PercentComplete, 0)},
+		{r.t("required_rate"), rate(p.RequiredWeeklyRate)},
+		{r.t("recent_rate"), rate(p.RecentWeeklyRate)},
+		{r.t("on_track"), yesNo},
+	}, 3)
+
+	// Progress bar.
+	pct := math.Max(0, math.Min(100, p.PercentComplete))
+	y := r.pdf.GetY() + 1
+	r.pdf.SetFillColor(230, 230, 230)
+	r.pdf.Rect(margin, y, contentW, 2.5, "F")
+	r.pdf.SetFillColor(76, 175, 80)
+	r.pdf.Rect(margin, y, contentW*pct/100, 2.5, "F")
+	r.pdf.SetY(y + 3.5)
+}
+
+func (r *renderer) composition() {
+	r.section(r.t("composition"))
+	if len(r.data.Measurements) == 0 && len(r.data.Indicators) == 0 {
+		r.note(r.t("no_composition"))
+		return
+	}
+
+	var rows [][2]string
+	for _, m := range r.data.Measurements {
+		decimals := 1
+		if kind, ok := domain.LookupMeasurementKind(m.Kind); ok {
+			decimals = kind.Precision
+		}
+		value := r.number(m.Value, decimals) + " " + m.Unit
+		if m.Unit == "%" {
+			value = r.percent(m.Value, decimals)
+		}
+		if t, err := calc.ParseDate(m.Date); err == nil {
+			value += " (" + r.date(t) + ")"
+		}
+		rows = append(rows, [2]string{r.t(m.Kind), value})
+	}
+	for _, ind := range r.data.Indicators {
+		value := r.number(ind.Value, 2)
+		if ind.Unit == "%" {
+			value = r.percent(ind.Value, 1)
+		}
+		rows = append(rows, [2]string{r.t(ind.Key), value + " · " + r.t(ind.Category)})
+	}
+	r.pairs(rows, 2)
+}
+
+func (r *renderer) note(text string) {
+	r.pdf.SetFont(fontFamily, "", 8.5)
+	r.pdf.SetTextColor(110, 110, 110)
+	r.pdf.CellFormat(contentW, 5, text, "", 1, "L", false, 0, "")
+	r.pdf.SetTextColor(0, 0, 0)
+}
+
+func (r *renderer) footer() {
+	r.pdf.SetXY(margin, 297-margin-5)
+	r.pdf.SetFont(fontFamily, "", 7)
+	r.pdf.SetTextColor(140, 140, 140)
+	r.pdf.CellFormat(contentW, 5, r.t("disclaimer"), "T", 0, "C", false, 0, "")
+}
+
+func dash(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return "-"
+	}
+	return s
+}
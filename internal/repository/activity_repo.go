@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	stepColumns    = `id, user_id, date, steps, created_at, updated_at`
+	workoutColumns = `id, user_id, date, type, duration_min, met, weight_kg, calories, created_at`
+)
+
+type ActivityRepository struct {
+	db *sql.DB
+}
+
+func NewActivityRepository(db *sql.DB) *ActivityRepository {
+	return &ActivityRepository{db: db}
+}
+
+// SaveSteps stores the step count of a day, replacing an earlier count for
+// the same day.
+func (r *ActivityRepository) SaveSteps(s *domain.StepCount) (*domain.StepCount, error) {
+	if _, err := r.db.Exec(
+		`INSERT INTO user_step_counts (user_id, date, steps) VALUES (?, ?, ?)
+		 ON DUPLICATE KEY UPDATE steps = VALUES(steps)`,
+		s.UserID, s.Date, s.Steps,
+	); err != nil {
+		return nil, fmt.Errorf("failed to save steps: %w", err)
+	}
+	saved, err := scanStepCount(r.db.QueryRow(
+		`SELECT `+stepColumns+` FROM user_step_counts WHERE user_id = ? AND date = ?`, s.UserID, s.Date,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get steps: %w", err)
+	}
+	return saved, nil
+}
+
+// GetStepsBetween lists step counts dated from..to inclusive (YYYY-MM-DD).
+func (r *ActivityRepository) GetStepsBetween(userID int64, from, to string) ([]domain.StepCount, error) {
+	rows, err := r.db.Query(
+		`SELECT `+stepColumns+`
+		 FROM user_step_counts
+		 WHERE user_id = ? AND date >= ? AND date <= ?
+		 ORDER BY date ASC`, userID, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list steps: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []domain.StepCount
+	for rows.Next() {
+		s, err := scanStepCount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan steps: %w", err)
+		}
+		counts = append(counts, *s)
+	}
+	return counts, rows.Err()
+}
+
+func (r *ActivityRepository) DeleteSteps(userID int64, date string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM user_step_counts WHERE user_id = ? AND date = ?`, userID, date)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete steps: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *ActivityRepository) CreateWorkout(w *domain.Workout) (int64, error) {
+	result, err := r.db.Exec(
+		`INSERT INTO user_workouts (user_id, date, type, duration_min, met, weight_kg, calories)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		w.UserID, w.Date, w.Type, w.DurationMin, w.MET, w.WeightKG, w.Calories,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create workout: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+func (r *ActivityRepository) GetWorkoutByIDAndUserID(id, userID int64) (*domain.Workout, error) {
+	w, err := scanWorkout(r.db.QueryRow(
+		`SELECT `+workoutColumns+` FROM user_workouts WHERE id = ? AND user_id = ?`, id, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workout: %w", err)
+	}
+	return w, nil
+}
+
+// GetWorkoutsBetween lists workouts dated from..to inclusive (YYYY-MM-DD).
+func (r *ActivityRepository) GetWorkoutsBetween(userID int64, from, to string) ([]domain.Workout, error) {
+	rows, err := r.db.Query(
+		`SELECT `+workoutColumns+`
+		 FROM user_workouts
+		 WHERE user_id = ? AND date >= ? AND date <= ?
+		 ORDER BY date ASC, id ASC`, userID, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workouts: %w", err)
+	}
+	defer rows.Close()
+
+	var workouts []domain.Workout
+	for rows.Next() {
+		w, err := scanWorkout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workout: %w", err)
+		}
+		workouts = append(workouts, *w)
+	}
+	return workouts, rows.Err()
+}
+
+func (r *ActivityRepository) DeleteWorkoutByIDAndUserID(id, userID int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM user_workouts WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete workout: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func scanStepCount(row rowScanner) (*domain.StepCount, error) {
+	var s domain.StepCount
+	if err := row.Scan(&s.ID, &s.UserID, &s.Date, &s.Steps, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func scanWorkout(row rowScanner) (*domain.Workout, error) {
+	var w domain.Workout
+	if err := row.Scan(&w.ID, &w.UserID, &w.Date, &w.Type, &w.DurationMin, &w.MET, &w.WeightKG, &w.Calories, &w.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const foodColumns = `id, user_id, date, meal_type, name, calories, protein, carbs, fat, created_at`
+
+type FoodRepository struct {
+	db *sql.DB
+}
+
+func NewFoodRepository(db *sql.DB) *FoodRepository {
+	return &FoodRepository{db: db}
+}
+
+func (r *FoodRepository) Create(e *domain.FoodEntry) (int64, error) {
+	result, err := r.db.Exec(
+		`INSERT INTO user_food_entries (user_id, date, meal_type, name, calories, protein, carbs, fat)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.UserID, e.Date, e.MealType, e.Name, e.Calories, e.Protein, e.Carbs, e.Fat,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create food entry: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+func (r *FoodRepository) GetByIDAndUserID(id, userID int64) (*domain.FoodEntry, error) {
+	e, err := scanFoodEntry(r.db.QueryRow(
+		`SELECT `+foodColumns+` FROM user_food_entries WHERE id = ? AND user_id = ?`, id, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get food entry: %w", err)
+	}
+	return e, nil
+}
+
+// GetByUserIDBetween lists entries dated from..to inclusive (YYYY-MM-DD).
+func (r *FoodRepository) GetByUserIDBetween(userID int64, from, to string) ([]domain.FoodEntry, error) {
+	rows, err := r.db.Query(
+		`SELECT `+foodColumns+`
+		 FROM user_food_entries
+		 WHERE user_id = ? AND date >= ? AND date <= ?
+		 ORDER BY date ASC, id ASC`, userID, from, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list food entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.FoodEntry
+	for rows.Next() {
+		e, err := scanFoodEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan food entry: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *FoodRepository) DeleteByIDAndUserID(id, userID int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM user_food_entries WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete food entry: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func scanFoodEntry(row rowScanner) (*domain.FoodEntry, error) {
+	var e domain.FoodEntry
+	err := row.Scan(&e.ID, &e.UserID, &e.Date, &e.MealType, &e.Name, &e.Calories, &e.Protein, &e.Carbs, &e.Fat, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
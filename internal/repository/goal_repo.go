@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const goalColumns = `id, user_id, goal_type, target_value, start_value, start_weight, start_bmi,
+		start_date, target_date, status, created_at, updated_at`
+
+type GoalRepository struct {
+	db *sql.DB
+}
+
+func NewGoalRepository(db *sql.DB) *GoalRepository {
+	return &GoalRepository{db: db}
+}
+
+func (r *GoalRepository) Create(g *domain.Goal) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin goal transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE user_goals SET status = ? WHERE user_id = ? AND status = ?`,
+		domain.GoalStatusAbandoned, g.UserID, domain.GoalStatusActive,
+	); err != nil {
+		return 0, fmt.Errorf("failed to close previous goals: %w", err)
+	}
+
+	result, err := tx.Exec(
+		`INSERT INTO user_goals (user_id, goal_type, target_value, start_value, start_weight, start_bmi, start_date, target_date, status)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		g.UserID, g.Type, g.TargetValue, g.StartValue, g.StartWeight, g.StartBMI, g.StartDate, g.TargetDate, g.Status,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create goal: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (r *GoalRepository) GetByIDAndUserID(id, userID int64) (*domain.Goal, error) {
+	g, err := scanGoal(r.db.QueryRow(
+		`SELECT `+goalColumns+` FROM user_goals WHERE id = ? AND user_id = ?`, id, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %w", err)
+	}
+	return g, nil
+}
+
+func (r *GoalRepository) GetActiveByUserID(userID int64) (*domain.Goal, error) {
+	g, err := scanGoal(r.db.QueryRow(
+		`SELECT `+goalColumns+` FROM user_goals WHERE user_id = ? AND status = ?
+		 ORDER BY id DESC LIMIT 1`, userID, domain.GoalStatusActive,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active goal: %w", err)
+	}
+	return g, nil
+}
+
+func (r *GoalRepository) GetByUserID(userID int64) ([]domain.Goal, error) {
+	rows, err := r.db.Query(
+		`SELECT `+goalColumns+` FROM user_goals WHERE user_id = ? ORDER BY id DESC`, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list goals: %w", err)
+	}
+	defer rows.Close()
+
+	var goals []domain.Goal
+	for rows.Next() {
+		g, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
+		}
+		goals = append(goals, *g)
+	}
+	return goals, rows.Err()
+}
+
+// UpdateStatus closes an active goal; a goal that is already closed keeps
+// its status.
+func (r *GoalRepository) UpdateStatus(id int64, status string) error {
+	_, err := r.db.Exec(
+		`UPDATE user_goals SET status = ? WHERE id = ? AND status = ?`, status, id, domain.GoalStatusActive,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update goal status: %w", err)
+	}
+	return nil
+}
+
+func (r *GoalRepository) DeleteByIDAndUserID(id, userID int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM user_goals WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete goal: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanGoal(row rowScanner) (*domain.Goal, error) {
+	var g domain.Goal
+	err := row.Scan(&g.ID, &g.UserID, &g.Type, &g.TargetValue, &g.StartValue, &g.StartWeight, &g.StartBMI,
+		&g.StartDate, &g.TargetDate, &g.Status, &g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
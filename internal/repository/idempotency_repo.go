@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve claims key for the account. When the key is already taken by an
+// unexpired entry, that entry is returned with created set to false so the
+// caller can replay or reject the request.
+func (r *IdempotencyRepository) Reserve(accountID int64, key, requestHash string, expiresAt time.Time) (*domain.IdempotencyKey, bool, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		result, err := r.db.Exec(
+			`INSERT INTO idempotency_keys (account_id, idem_key, request_hash, expires_at) VALUES (?, ?, ?, ?)`,
+			accountID, key, requestHash, expiresAt,
+		)
+		if err == nil {
+			id, err := result.LastInsertId()
+			if err != nil {
+				return nil, false, err
+			}
+			return &domain.IdempotencyKey{
+				ID: id, AccountID: accountID, Key: key, RequestHash: requestHash, ExpiresAt: expiresAt,
+			}, true, nil
+		}
+
+		var mysqlErr *mysql.MySQLError
+		if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1062 {
+			return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+
+		existing, err := r.get(accountID, key)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil && existing.ExpiresAt.After(time.Now()) {
+			return existing, false, nil
+		}
+		if _, err := r.db.Exec(
+			`DELETE FROM idempotency_keys WHERE account_id = ? AND idem_key = ? AND expires_at <= NOW()`,
+			accountID, key,
+		); err != nil {
+			return nil, false, fmt.Errorf("failed to expire idempotency key: %w", err)
+		}
+	}
+	return nil, false, errors.New("failed to reserve idempotency key")
+}
+
+func (r *IdempotencyRepository) get(accountID int64, key string) (*domain.IdempotencyKey, error) {
+	var k domain.IdempotencyKey
+	var status sql.NullInt64
+	var contentType, location, etag sql.NullString
+	err := r.db.QueryRow(
+		`SELECT id, account_id, idem_key, request_hash, status_code, content_type, location, etag, response_body, expires_at
+		 FROM idempotency_keys
+		 WHERE account_id = ? AND idem_key = ?`,
+		accountID, key,
+	).Scan(&k.ID, &k.AccountID, &k.Key, &k.RequestHash, &status, &contentType, &location, &etag, &k.ResponseBody, &k.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	if status.Valid {
+		code := int(status.Int64)
+		k.StatusCode = &code
+	}
+	k.ContentType = contentType.String
+	k.Location = location.String
+	k.ETag = etag.String
+	return &k, nil
+}
+
+func (r *IdempotencyRepository) Complete(id int64, statusCode int, contentType, location, etag string, body []byte) error {
+	_, err := r.db.Exec(
+		`UPDATE idempotency_keys SET status_code = ?, content_type = ?, location = ?, etag = ?, response_body = ? WHERE id = ?`,
+		statusCode, contentType, location, etag, body, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Delete(id int64) error {
+	if _, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired() (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
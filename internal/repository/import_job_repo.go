@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+type ImportJobRepository struct {
+	db *sql.DB
+}
+
+func NewImportJobRepository(db *sql.DB) *ImportJobRepository {
+	return &ImportJobRepository{db: db}
+}
+
+func (r *ImportJobRepository) Create(job *domain.ImportJob) (int64, error) {
+	result, err := r.db.Exec(
+		`INSERT INTO import_jobs (user_id, source, status, bytes_total) VALUES (?, ?, ?, ?)`,
+		job.UserID, job.Source, job.Status, job.BytesTotal,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create import job: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+func (r *ImportJobRepository) GetByIDAndUserID(id, userID int64) (*domain.ImportJob, error) {
+	var job domain.ImportJob
+	var report sql.NullString
+	err := r.db.QueryRow(
+		`SELECT id, user_id, source, status, bytes_total, bytes_processed, records_found, error, report, created_at, updated_at
+		 FROM import_jobs WHERE id = ? AND user_id = ?`, id, userID,
+	).Scan(&job.ID, &job.UserID, &job.Source, &job.Status, &job.BytesTotal, &job.BytesProcessed,
+		&job.RecordsFound, &job.Error, &report, &job.CreatedAt, &job.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+	if report.Valid {
+		job.Report = &domain.ImportReport{}
+		if err := json.Unmarshal([]byte(report.String), job.Report); err != nil {
+			return nil, fmt.Errorf("failed to decode import report: %w", err)
+		}
+	}
+	return &job, nil
+}
+
+func (r *ImportJobRepository) UpdateProgress(id int64, status string, bytesProcessed, bytesTotal int64) error {
+	_, err := r.db.Exec(
+		`UPDATE import_jobs SET status = ?, bytes_processed = ?, bytes_total = ? WHERE id = ?`,
+		status, bytesProcessed, bytesTotal, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+	return nil
+}
+
+func (r *ImportJobRepository) Complete(id int64, report *domain.ImportReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode import report: %w", err)
+	}
+	_, err = r.db.Exec(
+		`UPDATE import_jobs SET status = ?, bytes_processed = bytes_total, records_found = ?, report = ? WHERE id = ?`,
+		domain.ImportJobCompleted, report.Total, string(data), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to complete import job: %w", err)
+	}
+	return nil
+}
+
+func (r *ImportJobRepository) Fail(id int64, message string) error {
+	if len(message) > 255 {
+		message = message[:255]
+	}
+	_, err := r.db.Exec(
+		`UPDATE import_jobs SET status = ?, error = ? WHERE id = ?`,
+		domain.ImportJobFailed, message, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark import job failed: %w", err)
+	}
+	return nil
+}
+
+// FailInterrupted marks jobs left unfinished by a previous process as failed.
+func (r *ImportJobRepository) FailInterrupted() error {
+	_, err := r.db.Exec(
+		`UPDATE import_jobs SET status = ?, error = ? WHERE status IN (?, ?)`,
+		domain.ImportJobFailed, "interrupted by server restart", domain.ImportJobQueued, domain.ImportJobRunning,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reset interrupted import jobs: %w", err)
+	}
+	return nil
+}
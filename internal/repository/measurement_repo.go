@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+type MeasurementRepository struct {
+	db *sql.DB
+}
+
+func NewMeasurementRepository(db *sql.DB) *MeasurementRepository {
+	return &MeasurementRepository{db: db}
+}
+
+func (r *MeasurementRepository) Create(m *domain.Measurement) (int64, error) {
+	result, err := r.db.Exec(
+		`INSERT INTO user_measurements (user_id, kind, value, unit, date) VALUES (?, ?, ?, ?, ?)`,
+		m.UserID, m.Kind, m.Value, m.Unit, m.Date,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create measurement: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+func (r *MeasurementRepository) GetByIDAndUserID(id, userID int64) (*domain.Measurement, error) {
+	var m domain.Measurement
+	err := r.db.QueryRow(
+		`SELECT id, user_id, kind, value, unit, date, created_at
+		 FROM user_measurements WHERE id = ? AND user_id = ?`, id, userID,
+	).Scan(&m.ID, &m.UserID, &m.Kind, &m.Value, &m.Unit, &m.Date, &m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get measurement: %w", err)
+	}
+	return &m, nil
+}
+
+func (r *MeasurementRepository) GetByUserID(userID int64, kind string) ([]domain.Measurement, error) {
+	query := `SELECT id, user_id, kind, value, unit, date, created_at
+		 FROM user_measurements WHERE user_id = ?`
+	args := []interface{}{userID}
+	if kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	query += ` ORDER BY date ASC, id ASC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list measurements: %w", err)
+	}
+	defer rows.Close()
+
+	var measurements []domain.Measurement
+	for rows.Next() {
+		var m domain.Measurement
+		if err := rows.Scan(&m.ID, &m.UserID, &m.Kind, &m.Value, &m.Unit, &m.Date, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan measurement: %w", err)
+		}
+		measurements = append(measurements, m)
+	}
+	return measurements, rows.Err()
+}
+
+func (r *MeasurementRepository) GetLatestByUserID(userID int64) (map[string]domain.Measurement, error) {
+	measurements, err := r.GetByUserID(userID, "")
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]domain.Measurement)
+	for _, m := range measurements {
+		latest[m.Kind] = m
+	}
+	return latest, nil
+}
+
+func (r *MeasurementRepository) DeleteByIDAndUserID(id, userID int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM user_measurements WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete measurement: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Import inserts measurements in a single transaction, skipping any kind that
+// already has a value for the user on that date. The returned slice marks
+// which input measurements were duplicates.
+func (r *MeasurementRepository) Import(userID int64, measurements []domain.Measurement) ([]bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT kind, date FROM user_measurements WHERE user_id = ? FOR UPDATE`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock measurements: %w", err)
+	}
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var kind, date string
+		if err := rows.Scan(&kind, &date); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan measurement: %w", err)
+		}
+		seen[kind+"|"+date] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO user_measurements (user_id, kind, value, unit, date) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare import: %w", err)
+	}
+	defer stmt.Close()
+
+	duplicates := make([]bool, len(measurements))
+	for i := range measurements {
+		m := &measurements[i]
+		m.UserID = userID
+		key := m.Kind + "|" + m.Date
+		if seen[key] {
+			duplicates[i] = true
+			continue
+		}
+		seen[key] = true
+
+		result, err := stmt.Exec(m.UserID, m.Kind, m.Value, m.Unit, m.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import measurement: %w", err)
+		}
+		if m.ID, err = result.LastInsertId(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return duplicates, nil
+}
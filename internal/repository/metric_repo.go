@@ -2,11 +2,17 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
 	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/uuid"
 )
 
+const metricColumns = `id, uuid, version, user_id, date, weight, height, bmi, weight_diff, body_metric, created_at, suspect, suspect_reason, confirmed, modified_at, note`
+
 type MetricRepository struct {
 	db *sql.DB
 }
@@ -15,23 +21,82 @@ func NewMetricRepository(db *sql.DB) *MetricRepository {
 	return &MetricRepository{db: db}
 }
 
-func (r *MetricRepository) Create(m *domain.UserMetric) (int64, error) {
-	result, err := r.db.Exec(
-		`INSERT INTO user_metrics (user_id, date, weight, height, bmi, weight_diff, body_metric, created_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		m.UserID, m.Date, m.Weight, m.Height, m.BMI, m.WeightDiff, m.BodyMetric, m.CreatedAt,
+// Create inserts m and then lets recompute refresh the derived fields of the
+// user's whole history, as Update does, so a backdated entry also corrects the
+// weight_diff of the entry after it. m receives the stored result.
+func (r *MetricRepository) Create(m *domain.UserMetric, recompute func([]domain.UserMetric)) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUserSyncSeq(tx, m.UserID); err != nil {
+		return 0, err
+	}
+	all, err := lockMetrics(tx, m.UserID)
+	if err != nil {
+		return 0, err
+	}
+	if m.ID, err = insertMetric(tx, m); err != nil {
+		return 0, err
+	}
+	all = append(all, *m)
+
+	before := append([]domain.UserMetric(nil), all...)
+	recompute(all)
+	if err := saveDerived(tx, before, all); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit metric: %w", err)
+	}
+	*m = all[len(all)-1]
+	return m.ID, nil
+}
+
+// insertMetric stamps a new metric with a UUID (unless the caller supplied
+// one) and the next sync sequence value.
+func insertMetric(tx *sql.Tx, m *domain.UserMetric) (int64, error) {
+	seq, err := nextUserSyncSeq(tx, m.UserID)
+	if err != nil {
+		return 0, err
+	}
+	if m.UUID == "" {
+		m.UUID = uuid.New()
+	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
+	if m.ModifiedAt.IsZero() {
+		m.ModifiedAt = time.Now().UTC()
+	}
+	result, err := tx.Exec(
+		`INSERT INTO user_metrics (uuid, version, modified_at, sync_seq, user_id, date, weight, height, bmi, weight_diff, body_metric, created_at, suspect, suspect_reason, confirmed, note)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		m.UUID, m.Version, m.ModifiedAt, seq, m.UserID, m.Date, m.Weight, m.Height, m.BMI, m.WeightDiff, m.BodyMetric, m.CreatedAt, m.Suspect, m.SuspectReason, m.Confirmed, m.Note,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create metric: %w", err)
 	}
-	return result.LastInsertId()
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if len(m.Tags) > 0 {
+		if err := setMetricTags(tx, m.UserID, id, m.Tags); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
 }
 
 func (r *MetricRepository) GetByUserID(userID int64) ([]domain.UserMetric, error) {
 	rows, err := r.db.Query(
-		`SELECT id, user_id, date, weight, height, bmi, weight_diff, body_metric, created_at
+		`SELECT `+metricColumns+`
 		 FROM user_metrics
-		 WHERE user_id = ?
+		 WHERE user_id = ? AND deleted_at IS NULL
 		 ORDER BY created_at ASC, id ASC`, userID,
 	)
 	if err != nil {
@@ -39,13 +104,341 @@ func (r *MetricRepository) GetByUserID(userID int64) ([]domain.UserMetric, error
 	}
 	defer rows.Close()
 
+	metrics, err := scanMetrics(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.attachTags(userID, metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+func (r *MetricRepository) GetByIDAndUserID(id, userID int64) (*domain.UserMetric, error) {
+	m, err := scanMetric(r.db.QueryRow(
+		`SELECT `+metricColumns+` FROM user_metrics WHERE id = ? AND user_id = ? AND deleted_at IS NULL`, id, userID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	metrics := []domain.UserMetric{m}
+	if err := r.attachTags(userID, metrics); err != nil {
+		return nil, err
+	}
+	return &metrics[0], nil
+}
+
+// attachTags fills in the tag names of metrics, which all belong to userID.
+func (r *MetricRepository) attachTags(userID int64, metrics []domain.UserMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	rows, err := r.db.Query(
+		`SELECT mt.metric_id, t.name
+		 FROM user_metric_tags mt JOIN user_tags t ON t.id = mt.tag_id
+		 WHERE t.user_id = ?
+		 ORDER BY t.name ASC`, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list metric tags: %w", err)
+	}
+	defer rows.Close()
+
+	byMetric := make(map[int64][]string)
+	for rows.Next() {
+		var metricID int64
+		var name string
+		if err := rows.Scan(&metricID, &name); err != nil {
+			return fmt.Errorf("failed to scan metric tag: %w", err)
+		}
+		byMetric[metricID] = append(byMetric[metricID], name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range metrics {
+		metrics[i].Tags = byMetric[metrics[i].ID]
+	}
+	return nil
+}
+
+// setMetricTags replaces the tags of a metric, adding names the profile has
+// not used before to its vocabulary.
+func setMetricTags(tx *sql.Tx, userID, metricID int64, names []string) error {
+	if _, err := tx.Exec(`DELETE FROM user_metric_tags WHERE metric_id = ?`, metricID); err != nil {
+		return fmt.Errorf("failed to clear metric tags: %w", err)
+	}
+	for _, name := range names {
+		result, err := tx.Exec(
+			`INSERT INTO user_tags (user_id, name) VALUES (?, ?)
+			 ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`, userID, name,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create tag: %w", err)
+		}
+		tagID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`INSERT IGNORE INTO user_metric_tags (metric_id, tag_id) VALUES (?, ?)`, metricID, tagID,
+		); err != nil {
+			return fmt.Errorf("failed to tag metric: %w", err)
+		}
+	}
+	return nil
+}
+
+// Update saves the editable fields of m (date, weight, height, note, tags and
+// confirmed) when the row is still at version (0 skips the check),
+// then lets recompute refresh the derived fields of the whole history. m
+// receives the stored result.
+func (r *MetricRepository) Update(m *domain.UserMetric, version int64, recompute func([]domain.UserMetric)) error {
+	return r.modify(m.UserID, m.ID, version, recompute, func(tx *sql.Tx, seq int64) error {
+		_, err := tx.Exec(
+			`UPDATE user_metrics SET date = ?, weight = ?, height = ?, note = ?, confirmed = ?,
+			 version = version + 1, modified_at = NOW(3), sync_seq = ?
+			 WHERE id = ?`,
+			m.Date, m.Weight, m.Height, m.Note, m.Confirmed, seq, m.ID,
+		)
+		if err != nil {
+			return err
+		}
+		return setMetricTags(tx, m.UserID, m.ID, m.Tags)
+	}, m)
+}
+
+// Delete tombstones a metric so synced clients learn about the removal.
+func (r *MetricRepository) Delete(userID, id, version int64, recompute func([]domain.UserMetric)) error {
+	return r.modify(userID, id, version, recompute, func(tx *sql.Tx, seq int64) error {
+		_, err := tx.Exec(
+			`UPDATE user_metrics SET deleted_at = NOW(3), version = version + 1, modified_at = NOW(3), sync_seq = ? WHERE id = ?`,
+			seq, id,
+		)
+		return err
+	}, nil)
+}
+
+func (r *MetricRepository) modify(
+	userID, id, version int64,
+	recompute func([]domain.UserMetric),
+	write func(tx *sql.Tx, seq int64) error,
+	result *domain.UserMetric,
+) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUserSyncSeq(tx, userID); err != nil {
+		return err
+	}
+	all, err := lockMetrics(tx, userID)
+	if err != nil {
+		return err
+	}
+	index := -1
+	for i := range all {
+		if all[i].ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return sql.ErrNoRows
+	}
+	if version > 0 && all[index].Version != version {
+		return ErrVersionMismatch
+	}
+
+	seq, err := nextUserSyncSeq(tx, userID)
+	if err != nil {
+		return err
+	}
+	if err := write(tx, seq); err != nil {
+		return fmt.Errorf("failed to modify metric: %w", err)
+	}
+
+	if result != nil {
+		m := &all[index]
+		m.Date, m.Weight, m.Height = result.Date, result.Weight, result.Height
+		m.Note, m.Tags, m.Confirmed = result.Note, result.Tags, result.Confirmed
+		m.Version++
+	} else {
+		all = append(all[:index], all[index+1:]...)
+	}
+	before := append([]domain.UserMetric(nil), all...)
+	recompute(all)
+	if err := saveDerived(tx, before, all); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit metric: %w", err)
+	}
+	if result != nil {
+		*result = all[index]
+	}
+	return nil
+}
+
+// Stream calls fn for each of the user's metrics in list order without
+// loading the whole history into memory. Iteration stops at fn's first error.
+func (r *MetricRepository) Stream(userID int64, fn func(domain.UserMetric) error) error {
+	rows, err := r.db.Query(
+		`SELECT `+metricColumns+`
+		 FROM user_metrics
+		 WHERE user_id = ? AND deleted_at IS NULL
+		 ORDER BY created_at ASC, id ASC`, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list metrics: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := scanMetric(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Import inserts metrics in a single transaction, skipping any whose calendar
+// day already has an entry for the user. recompute then sees the user's full
+// history and may rewrite derived fields, which are saved in the same
+// transaction. The returned slice marks which input metrics were duplicates.
+func (r *MetricRepository) Import(
+	userID int64,
+	metrics []domain.UserMetric,
+	recompute func([]domain.UserMetric),
+) ([]bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUserSyncSeq(tx, userID); err != nil {
+		return nil, err
+	}
+	all, err := lockMetrics(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[string]bool, len(all))
+	for _, m := range all {
+		if day, err := calc.ParseDate(m.Date); err == nil {
+			days[day.Format(calc.DateLayout)] = true
+		}
+	}
+
+	duplicates := make([]bool, len(metrics))
+	for i := range metrics {
+		m := &metrics[i]
+		m.UserID = userID
+		if day, err := calc.ParseDate(m.Date); err == nil {
+			key := day.Format(calc.DateLayout)
+			if days[key] {
+				duplicates[i] = true
+				continue
+			}
+			days[key] = true
+		}
+
+		if m.ID, err = insertMetric(tx, m); err != nil {
+			return nil, fmt.Errorf("failed to import metric: %w", err)
+		}
+		all = append(all, *m)
+	}
+
+	before := append([]domain.UserMetric(nil), all...)
+	recompute(all)
+	if err := saveDerived(tx, before, all); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return duplicates, nil
+}
+
+// lockMetrics locks the user's live metrics. Callers take the account's sync
+// sequence lock first; see lockSyncSeq.
+func lockMetrics(tx *sql.Tx, userID int64) ([]domain.UserMetric, error) {
+	rows, err := tx.Query(
+		`SELECT `+metricColumns+` FROM user_metrics WHERE user_id = ? AND deleted_at IS NULL ORDER BY id ASC FOR UPDATE`, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock metrics: %w", err)
+	}
+	defer rows.Close()
+	return scanMetrics(rows)
+}
+
+// saveDerived writes back recomputed height, BMI, weight_diff, body_metric and
+// the suspect flag.
+// Changed rows get a new sync sequence so clients pick them up, but keep their
+// version and modified_at: derived fields are never edited by clients, so they
+// must not turn a client's next edit into a conflict.
+func saveDerived(tx *sql.Tx, before, after []domain.UserMetric) error {
+	for i := range after {
+		if derivedEqual(before[i], after[i]) {
+			continue
+		}
+		m := &after[i]
+		seq, err := nextUserSyncSeq(tx, m.UserID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`UPDATE user_metrics SET height = ?, bmi = ?, weight_diff = ?, body_metric = ?, suspect = ?, suspect_reason = ?, sync_seq = ?
+			 WHERE id = ?`,
+			m.Height, m.BMI, m.WeightDiff, m.BodyMetric, m.Suspect, m.SuspectReason, seq, m.ID,
+		); err != nil {
+			return fmt.Errorf("failed to update derived fields: %w", err)
+		}
+	}
+	return nil
+}
+
+func derivedEqual(a, b domain.UserMetric) bool {
+	eqFloat := func(x, y *float64) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	}
+	eqString := func(x, y *string) bool {
+		return (x == nil && y == nil) || (x != nil && y != nil && *x == *y)
+	}
+	return a.Height == b.Height && a.BMI == b.BMI && eqFloat(a.WeightDiff, b.WeightDiff) && eqString(a.BodyMetric, b.BodyMetric) &&
+		a.Suspect == b.Suspect && eqString(a.SuspectReason, b.SuspectReason)
+}
+
+func scanMetrics(rows *sql.Rows) ([]domain.UserMetric, error) {
 	var metrics []domain.UserMetric
 	for rows.Next() {
-		var m domain.UserMetric
-		if err := rows.Scan(&m.ID, &m.UserID, &m.Date, &m.Weight, &m.Height, &m.BMI, &m.WeightDiff, &m.BodyMetric, &m.CreatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan metric: %w", err)
+		m, err := scanMetric(rows)
+		if err != nil {
+			return nil, err
 		}
 		metrics = append(metrics, m)
 	}
 	return metrics, rows.Err()
 }
+
+func scanMetric(rows rowScanner) (domain.UserMetric, error) {
+	var m domain.UserMetric
+	if err := rows.Scan(&m.ID, &m.UUID, &m.Version, &m.UserID, &m.Date, &m.Weight, &m.Height, &m.BMI, &m.WeightDiff, &m.BodyMetric, &m.CreatedAt, &m.Suspect, &m.SuspectReason, &m.Confirmed, &m.ModifiedAt, &m.Note); err != nil {
+		return m, fmt.Errorf("failed to scan metric: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const photoColumns = `id, user_id, metric_id, date, width, height, size_bytes, blob_key, thumb_key, created_at`
+
+type PhotoRepository struct {
+	db *sql.DB
+}
+
+func NewPhotoRepository(db *sql.DB) *PhotoRepository {
+	return &PhotoRepository{db: db}
+}
+
+func (r *PhotoRepository) Create(p *domain.Photo) (int64, error) {
+	result, err := r.db.Exec(
+		`INSERT INTO user_photos (user_id, metric_id, date, width, height, size_bytes, blob_key, thumb_key)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.UserID, p.MetricID, p.Date, p.Width, p.Height, p.SizeBytes, p.BlobKey, p.ThumbKey,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create photo: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetByUserID lists photos by date, limited to one metric when metricID is
+// non-zero.
+func (r *PhotoRepository) GetByUserID(userID, metricID int64) ([]domain.Photo, error) {
+	query := `SELECT ` + photoColumns + ` FROM user_photos WHERE user_id = ?`
+	args := []interface{}{userID}
+	if metricID != 0 {
+		query += ` AND metric_id = ?`
+		args = append(args, metricID)
+	}
+	query += ` ORDER BY date ASC, id ASC`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list photos: %w", err)
+	}
+	defer rows.Close()
+
+	var photos []domain.Photo
+	for rows.Next() {
+		p, err := scanPhoto(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan photo: %w", err)
+		}
+		photos = append(photos, *p)
+	}
+	return photos, rows.Err()
+}
+
+func (r *PhotoRepository) GetByIDAndUserID(id, userID int64) (*domain.Photo, error) {
+	p, err := scanPhoto(r.db.QueryRow(
+		`SELECT `+photoColumns+` FROM user_photos WHERE id = ? AND user_id = ?`, id, userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photo: %w", err)
+	}
+	return p, nil
+}
+
+func (r *PhotoRepository) DeleteByIDAndUserID(id, userID int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM user_photos WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete photo: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func scanPhoto(row rowScanner) (*domain.Photo, error) {
+	var p domain.Photo
+	err := row.Scan(&p.ID, &p.UserID, &p.MetricID, &p.Date, &p.Width, &p.Height, &p.SizeBytes, &p.BlobKey, &p.ThumbKey, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
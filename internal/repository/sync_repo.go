@@ -0,0 +1,487 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+var syncMetricColumns = "m." + strings.ReplaceAll(metricColumns, ", ", ", m.")
+
+type SyncRepository struct {
+	db *sql.DB
+}
+
+func NewSyncRepository(db *sql.DB) *SyncRepository {
+	return &SyncRepository{db: db}
+}
+
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// withExtra appends trailing columns to a scan so the shared scanUser and
+// scanMetric helpers can read sync metadata as well.
+type withExtra struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (s withExtra) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+type syncRow struct {
+	id         int64
+	userID     int64
+	accountID  int64
+	version    int64
+	modifiedAt time.Time
+	deleted    bool
+}
+
+// lastWriterWins accepts a change made on top of the stored version, or a
+// stale change that was made later than the stored row.
+func lastWriterWins(c domain.SyncChange, row syncRow) bool {
+	return c.BaseVersion == row.version || c.ModifiedAt.After(row.modifiedAt)
+}
+
+// Apply writes a batch of client changes in one transaction. Profiles are
+// applied before metrics so a batch can create a profile and its entries
+// together. validate checks each upserted metric against its profile, which
+// may have been created earlier in the same batch; a metric it returns field
+// errors for is rejected. recompute refreshes derived metric fields for every
+// profile whose history changed.
+func (r *SyncRepository) Apply(
+	accountID int64,
+	changes []domain.SyncChange,
+	validate func(*domain.User, *domain.UserMetric) []domain.FieldError,
+	recompute func(*domain.User, []domain.UserMetric),
+) ([]domain.SyncResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin sync transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockSyncSeq(tx, accountID); err != nil {
+		return nil, err
+	}
+	results := make([]domain.SyncResult, len(changes))
+	affected := make(map[int64]bool)
+	for _, entity := range []string{domain.SyncEntityProfile, domain.SyncEntityMetric} {
+		for i, c := range changes {
+			if c.Entity != entity {
+				continue
+			}
+			if entity == domain.SyncEntityProfile {
+				results[i], err = applyProfile(tx, accountID, c, affected)
+			} else {
+				results[i], err = applyMetric(tx, accountID, c, validate, affected)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for userID := range affected {
+		user, err := scanUser(tx.QueryRow(
+			`SELECT `+userColumns+` FROM users WHERE id = ? AND deleted_at IS NULL`, userID,
+		))
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		all, err := lockMetrics(tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		before := append([]domain.UserMetric(nil), all...)
+		recompute(user, all)
+		if err := saveDerived(tx, before, all); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit sync: %w", err)
+	}
+	return results, nil
+}
+
+func applyProfile(tx *sql.Tx, accountID int64, c domain.SyncChange, affected map[int64]bool) (domain.SyncResult, error) {
+	res := domain.SyncResult{Entity: c.Entity, UUID: c.UUID, Status: domain.SyncApplied}
+
+	var row syncRow
+	var deletedAt sql.NullTime
+	err := tx.QueryRow(
+		`SELECT id, account_id, version, modified_at, deleted_at FROM users WHERE uuid = ? FOR UPDATE`, c.UUID,
+	).Scan(&row.id, &row.accountID, &row.version, &row.modifiedAt, &deletedAt)
+	if err == sql.ErrNoRows {
+		if c.Op == domain.SyncOpDelete {
+			return res, nil
+		}
+		existing, err := hasLiveProfile(tx, accountID)
+		if err != nil {
+			return res, err
+		}
+		if existing {
+			res.Status, res.Error = domain.SyncRejected, ErrProfileExists.Error()
+			return res, nil
+		}
+		seq, err := nextSyncSeq(tx, accountID)
+		if err != nil {
+			return res, err
+		}
+		u := c.Profile
+		if _, err := tx.Exec(
+			`INSERT INTO users (account_id, uuid, version, modified_at, sync_seq, name, surname, gender, avatar, height, birth_of_date, weight_unit, height_unit)
+			 VALUES (?, ?, 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			accountID, c.UUID, c.ModifiedAt, seq, u.Name, u.Surname, u.Gender, u.Avatar, u.Height, u.BirthOfDate, u.WeightUnit, u.HeightUnit,
+		); err != nil {
+			return res, fmt.Errorf("failed to create synced user: %w", err)
+		}
+		res.Version = 1
+		return res, nil
+	}
+	if err != nil {
+		return res, fmt.Errorf("failed to lock user: %w", err)
+	}
+	row.deleted = deletedAt.Valid
+
+	if row.accountID != accountID {
+		res.Status, res.Error = domain.SyncRejected, "uuid belongs to another account"
+		return res, nil
+	}
+	if !lastWriterWins(c, row) {
+		current, err := profileChangeByID(tx, row.id)
+		if err != nil {
+			return res, err
+		}
+		res.Status, res.Version, res.Current = domain.SyncConflict, row.version, current
+		return res, nil
+	}
+	if c.Op == domain.SyncOpDelete && row.deleted {
+		res.Version = row.version
+		return res, nil
+	}
+	if c.Op == domain.SyncOpUpsert && row.deleted {
+		existing, err := hasLiveProfile(tx, accountID)
+		if err != nil {
+			return res, err
+		}
+		if existing {
+			res.Status, res.Error = domain.SyncRejected, ErrProfileExists.Error()
+			return res, nil
+		}
+	}
+
+	seq, err := nextSyncSeq(tx, accountID)
+	if err != nil {
+		return res, err
+	}
+	if c.Op == domain.SyncOpDelete {
+		if _, err := tx.Exec(
+			`UPDATE users SET deleted_at = NOW(3), version = version + 1, modified_at = ?, sync_seq = ? WHERE id = ?`,
+			c.ModifiedAt, seq, row.id,
+		); err != nil {
+			return res, fmt.Errorf("failed to delete synced user: %w", err)
+		}
+		if err := tombstoneMetrics(tx, accountID, row.id, c.ModifiedAt); err != nil {
+			return res, err
+		}
+	} else {
+		u := c.Profile
+		if _, err := tx.Exec(
+			`UPDATE users SET name = ?, surname = ?, gender = ?, avatar = ?, height = ?, birth_of_date = ?,
+			 weight_unit = ?, height_unit = ?, deleted_at = NULL, version = version + 1, modified_at = ?, sync_seq = ?
+			 WHERE id = ?`,
+			u.Name, u.Surname, u.Gender, u.Avatar, u.Height, u.BirthOfDate, u.WeightUnit, u.HeightUnit, c.ModifiedAt, seq, row.id,
+		); err != nil {
+			return res, fmt.Errorf("failed to update synced user: %w", err)
+		}
+		affected[row.id] = true
+	}
+	res.Version = row.version + 1
+	return res, nil
+}
+
+// tombstoneMetrics deletes a removed profile's entries so other devices
+// receive a tombstone for each of them.
+func tombstoneMetrics(tx *sql.Tx, accountID, userID int64, modifiedAt time.Time) error {
+	rows, err := tx.Query(`SELECT id FROM user_metrics WHERE user_id = ? AND deleted_at IS NULL FOR UPDATE`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to lock metrics: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan metric: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		seq, err := nextSyncSeq(tx, accountID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`UPDATE user_metrics SET deleted_at = NOW(3), version = version + 1, modified_at = ?, sync_seq = ? WHERE id = ?`,
+			modifiedAt, seq, id,
+		); err != nil {
+			return fmt.Errorf("failed to delete metric: %w", err)
+		}
+	}
+	return nil
+}
+
+func applyMetric(
+	tx *sql.Tx,
+	accountID int64,
+	c domain.SyncChange,
+	validate func(*domain.User, *domain.UserMetric) []domain.FieldError,
+	affected map[int64]bool,
+) (domain.SyncResult, error) {
+	res := domain.SyncResult{Entity: c.Entity, UUID: c.UUID, Status: domain.SyncApplied}
+
+	var profileID int64
+	if c.Op == domain.SyncOpUpsert {
+		profile, err := scanUser(tx.QueryRow(
+			`SELECT `+userColumns+` FROM users WHERE uuid = ? AND account_id = ? AND deleted_at IS NULL`, c.ProfileUUID, accountID,
+		))
+		if err == sql.ErrNoRows {
+			res.Status, res.Error = domain.SyncRejected, "profile not found"
+			return res, nil
+		}
+		if err != nil {
+			return res, fmt.Errorf("failed to get user: %w", err)
+		}
+		if errs := validate(profile, c.Metric); len(errs) > 0 {
+			res.Status, res.Error, res.Errors = domain.SyncRejected, "invalid metric data", errs
+			return res, nil
+		}
+		profileID = profile.ID
+	}
+
+	var row syncRow
+	var deletedAt sql.NullTime
+	err := tx.QueryRow(
+		`SELECT m.id, m.user_id, u.account_id, m.version, m.modified_at, m.deleted_at
+		 FROM user_metrics m JOIN users u ON u.id = m.user_id
+		 WHERE m.uuid = ? FOR UPDATE`, c.UUID,
+	).Scan(&row.id, &row.userID, &row.accountID, &row.version, &row.modifiedAt, &deletedAt)
+	if err == sql.ErrNoRows {
+		if c.Op == domain.SyncOpDelete {
+			return res, nil
+		}
+		m := *c.Metric
+		m.UUID, m.Version, m.ModifiedAt, m.UserID = c.UUID, 1, c.ModifiedAt, profileID
+		if _, err := insertMetric(tx, &m); err != nil {
+			return res, err
+		}
+		affected[profileID] = true
+		res.Version = 1
+		return res, nil
+	}
+	if err != nil {
+		return res, fmt.Errorf("failed to lock metric: %w", err)
+	}
+	row.deleted = deletedAt.Valid
+
+	if row.accountID != accountID {
+		res.Status, res.Error = domain.SyncRejected, "uuid belongs to another account"
+		return res, nil
+	}
+	if !lastWriterWins(c, row) {
+		current, err := metricChangeByID(tx, row.id)
+		if err != nil {
+			return res, err
+		}
+		res.Status, res.Version, res.Current = domain.SyncConflict, row.version, current
+		return res, nil
+	}
+	if c.Op == domain.SyncOpDelete && row.deleted {
+		res.Version = row.version
+		return res, nil
+	}
+
+	seq, err := nextSyncSeq(tx, accountID)
+	if err != nil {
+		return res, err
+	}
+	if c.Op == domain.SyncOpDelete {
+		if _, err := tx.Exec(
+			`UPDATE user_metrics SET deleted_at = NOW(3), version = version + 1, modified_at = ?, sync_seq = ? WHERE id = ?`,
+			c.ModifiedAt, seq, row.id,
+		); err != nil {
+			return res, fmt.Errorf("failed to delete synced metric: %w", err)
+		}
+	} else {
+		m := c.Metric
+		if _, err := tx.Exec(
+			`UPDATE user_metrics SET user_id = ?, date = ?, weight = ?, height = ?, created_at = ?, note = ?, confirmed = 0,
+			 deleted_at = NULL, version = version + 1, modified_at = ?, sync_seq = ?
+			 WHERE id = ?`,
+			profileID, m.Date, m.Weight, m.Height, m.CreatedAt, m.Note, c.ModifiedAt, seq, row.id,
+		); err != nil {
+			return res, fmt.Errorf("failed to update synced metric: %w", err)
+		}
+		affected[profileID] = true
+	}
+	affected[row.userID] = true
+	res.Version = row.version + 1
+	return res, nil
+}
+
+// Changes returns the account's profile and metric changes after since in
+// sequence order, and the checkpoint to resume from. A page never splits rows
+// that share a sequence value, so it may exceed limit slightly.
+func (r *SyncRepository) Changes(accountID, since int64, limit int) ([]domain.SyncChange, int64, bool, error) {
+	changes, err := r.changesUpTo(accountID, since, 0, limit+1)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	more := len(changes) > limit
+	if more {
+		upper := changes[limit-1].Seq
+		if changes, err = r.changesUpTo(accountID, since, upper, 0); err != nil {
+			return nil, 0, false, err
+		}
+	}
+
+	checkpoint := since
+	if len(changes) > 0 {
+		checkpoint = changes[len(changes)-1].Seq
+	}
+	return changes, checkpoint, more, nil
+}
+
+// changesUpTo lists changes with since < seq <= upper (no upper bound when 0),
+// keeping at most limit of each entity when limit is positive.
+func (r *SyncRepository) changesUpTo(accountID, since, upper int64, limit int) ([]domain.SyncChange, error) {
+	bounds := ` AND %s > ?`
+	args := []interface{}{accountID, since}
+	if upper > 0 {
+		bounds += ` AND %s <= ?`
+		args = append(args, upper)
+	}
+	order := ` ORDER BY %s ASC`
+	if limit > 0 {
+		order += fmt.Sprintf(` LIMIT %d`, limit)
+	}
+
+	profiles, err := profileChanges(r.db,
+		`SELECT `+userColumns+`, deleted_at, sync_seq FROM users WHERE account_id = ?`+
+			strings.ReplaceAll(bounds, "%s", "sync_seq")+fmt.Sprintf(order, "sync_seq"),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := metricChanges(r.db,
+		`SELECT `+syncMetricColumns+`, u.uuid, m.deleted_at, m.sync_seq
+		 FROM user_metrics m JOIN users u ON u.id = m.user_id
+		 WHERE u.account_id = ?`+strings.ReplaceAll(bounds, "%s", "m.sync_seq")+fmt.Sprintf(order, "m.sync_seq"),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := append(profiles, metrics...)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Seq < changes[j].Seq })
+	return changes, nil
+}
+
+func profileChangeByID(q queryer, id int64) (*domain.SyncChange, error) {
+	changes, err := profileChanges(q, `SELECT `+userColumns+`, deleted_at, sync_seq FROM users WHERE id = ?`, id)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return &changes[0], nil
+}
+
+func metricChangeByID(q queryer, id int64) (*domain.SyncChange, error) {
+	changes, err := metricChanges(q,
+		`SELECT `+syncMetricColumns+`, u.uuid, m.deleted_at, m.sync_seq
+		 FROM user_metrics m JOIN users u ON u.id = m.user_id
+		 WHERE m.id = ?`, id)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return &changes[0], nil
+}
+
+func profileChanges(q queryer, query string, args ...interface{}) ([]domain.SyncChange, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []domain.SyncChange
+	for rows.Next() {
+		var deletedAt sql.NullTime
+		var seq int64
+		u, err := scanUser(withExtra{row: rows, extra: []interface{}{&deletedAt, &seq}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		c := domain.SyncChange{
+			Entity: domain.SyncEntityProfile, UUID: u.UUID, Op: domain.SyncOpUpsert,
+			Version: u.Version, ModifiedAt: u.ModifiedAt, Seq: seq,
+		}
+		if deletedAt.Valid {
+			c.Op = domain.SyncOpDelete
+		} else if c.Data, err = json.Marshal(u); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+func metricChanges(q queryer, query string, args ...interface{}) ([]domain.SyncChange, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metric changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []domain.SyncChange
+	for rows.Next() {
+		var profileUUID string
+		var deletedAt sql.NullTime
+		var seq int64
+		m, err := scanMetric(withExtra{row: rows, extra: []interface{}{&profileUUID, &deletedAt, &seq}})
+		if err != nil {
+			return nil, err
+		}
+		c := domain.SyncChange{
+			Entity: domain.SyncEntityMetric, UUID: m.UUID, Op: domain.SyncOpUpsert, ProfileUUID: profileUUID,
+			Version: m.Version, ModifiedAt: m.ModifiedAt, Seq: seq,
+		}
+		if deletedAt.Valid {
+			c.Op = domain.SyncOpDelete
+		} else if c.Data, err = json.Marshal(m); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func TestLastWriterWins(t *testing.T) {
+	stored := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	row := syncRow{id: 1, version: 4, modifiedAt: stored}
+
+	tests := []struct {
+		name        string
+		baseVersion int64
+		modifiedAt  time.Time
+		want        bool
+	}{
+		{"made on the stored version", 4, stored.Add(-time.Hour), true},
+		{"stale but edited later", 2, stored.Add(time.Second), true},
+		{"stale and edited earlier", 2, stored.Add(-time.Second), false},
+		{"stale with the same timestamp", 3, stored, false},
+		{"new on the client", 0, stored.Add(-time.Minute), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := domain.SyncChange{BaseVersion: tt.baseVersion, ModifiedAt: tt.modifiedAt}
+			if got := lastWriterWins(c, row); got != tt.want {
+				t.Errorf("lastWriterWins = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
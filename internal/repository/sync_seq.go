@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// lockSyncSeq takes the account's sequence row lock without advancing the
+// sequence. Every transaction that writes synced rows calls it before locking
+// any of them, so the lock doubles as a per-account write lock: writers queue
+// on this one row first and never wait for each other's profile or metric
+// rows in opposite orders. It also makes checks such as hasLiveProfile safe
+// against a concurrent insert.
+func lockSyncSeq(tx *sql.Tx, accountID int64) error {
+	if _, err := tx.Exec(
+		`INSERT INTO sync_sequences (account_id, value) VALUES (?, 0)
+		 ON DUPLICATE KEY UPDATE value = value`, accountID,
+	); err != nil {
+		return fmt.Errorf("failed to lock sync sequence: %w", err)
+	}
+	return nil
+}
+
+// lockUserSyncSeq is lockSyncSeq for the account that owns the profile.
+func lockUserSyncSeq(tx *sql.Tx, userID int64) error {
+	accountID, err := userAccountID(tx, userID)
+	if err != nil {
+		return err
+	}
+	return lockSyncSeq(tx, accountID)
+}
+
+// nextSyncSeq reserves the next value of the account's change sequence used
+// by the sync feed. The feed is read per account, so each account has its own
+// counter row; its lock is held until tx ends, so sequence order always
+// matches commit order within the account and a checkpoint never skips a late
+// commit, while writes for different accounts never wait on each other.
+func nextSyncSeq(tx *sql.Tx, accountID int64) (int64, error) {
+	// The upsert takes the row's exclusive lock straight away; reading it
+	// first would take a shared lock that two transactions could deadlock on
+	// while upgrading.
+	if _, err := tx.Exec(
+		`INSERT INTO sync_sequences (account_id, value) VALUES (?, 1)
+		 ON DUPLICATE KEY UPDATE value = value + 1`, accountID,
+	); err != nil {
+		return 0, fmt.Errorf("failed to advance sync sequence: %w", err)
+	}
+	var seq int64
+	if err := tx.QueryRow(`SELECT value FROM sync_sequences WHERE account_id = ?`, accountID).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to read sync sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// nextUserSyncSeq is nextSyncSeq for the account that owns the profile.
+func nextUserSyncSeq(tx *sql.Tx, userID int64) (int64, error) {
+	accountID, err := userAccountID(tx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return nextSyncSeq(tx, accountID)
+}
+
+func userAccountID(tx *sql.Tx, userID int64) (int64, error) {
+	var accountID int64
+	if err := tx.QueryRow(`SELECT account_id FROM users WHERE id = ?`, userID).Scan(&accountID); err != nil {
+		return 0, fmt.Errorf("failed to get user account: %w", err)
+	}
+	return accountID, nil
+}
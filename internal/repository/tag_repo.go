@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+type TagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// Create adds name to the profile's vocabulary and returns its id. Adding a
+// name that already exists returns the existing tag.
+func (r *TagRepository) Create(userID int64, name string) (int64, error) {
+	result, err := r.db.Exec(
+		`INSERT INTO user_tags (user_id, name) VALUES (?, ?)
+		 ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`, userID, name,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+const tagSelect = `SELECT t.id, t.user_id, t.name, t.created_at, COUNT(m.id)
+	FROM user_tags t
+	LEFT JOIN user_metric_tags mt ON mt.tag_id = t.id
+	LEFT JOIN user_metrics m ON m.id = mt.metric_id AND m.deleted_at IS NULL`
+
+const tagGroupBy = ` GROUP BY t.id, t.user_id, t.name, t.created_at`
+
+// GetByUserID lists the vocabulary by name with the number of live metrics
+// using each tag.
+func (r *TagRepository) GetByUserID(userID int64) ([]domain.Tag, error) {
+	rows, err := r.db.Query(tagSelect+` WHERE t.user_id = ?`+tagGroupBy+` ORDER BY t.name ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []domain.Tag
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, *t)
+	}
+	return tags, rows.Err()
+}
+
+func (r *TagRepository) GetByIDAndUserID(id, userID int64) (*domain.Tag, error) {
+	t, err := scanTag(r.db.QueryRow(tagSelect+` WHERE t.id = ? AND t.user_id = ?`+tagGroupBy, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	return t, nil
+}
+
+// DeleteByIDAndUserID removes a tag from the vocabulary and from every
+// metric carrying it. Those metrics change with it, so each gets a new
+// version and sync sequence in the same transaction.
+func (r *TagRepository) DeleteByIDAndUserID(id, userID int64) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUserSyncSeq(tx, userID); err != nil {
+		return false, err
+	}
+	rows, err := tx.Query(
+		`SELECT m.id FROM user_metrics m
+		 JOIN user_metric_tags mt ON mt.metric_id = m.id
+		 JOIN user_tags t ON t.id = mt.tag_id
+		 WHERE t.id = ? AND t.user_id = ? AND m.deleted_at IS NULL
+		 ORDER BY m.id ASC FOR UPDATE`, id, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock tagged metrics: %w", err)
+	}
+	var metricIDs []int64
+	for rows.Next() {
+		var metricID int64
+		if err := rows.Scan(&metricID); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("failed to scan metric: %w", err)
+		}
+		metricIDs = append(metricIDs, metricID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec(`DELETE FROM user_tags WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete tag: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	for _, metricID := range metricIDs {
+		seq, err := nextUserSyncSeq(tx, userID)
+		if err != nil {
+			return false, err
+		}
+		if _, err := tx.Exec(
+			`UPDATE user_metrics SET version = version + 1, modified_at = NOW(3), sync_seq = ? WHERE id = ?`,
+			seq, metricID,
+		); err != nil {
+			return false, fmt.Errorf("failed to update tagged metric: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit tag delete: %w", err)
+	}
+	return true, nil
+}
+
+func scanTag(row rowScanner) (*domain.Tag, error) {
+	var t domain.Tag
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.CreatedAt, &t.Count); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -2,12 +2,24 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/uuid"
 )
 
+// ErrVersionMismatch is returned by conditional writes when the row has
+// moved past the version the caller expected.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// ErrProfileExists is returned when an account that already has a live
+// profile tries to create another.
+var ErrProfileExists = errors.New("user profile already exists for this account")
+
+const userColumns = `id, uuid, version, name, surname, gender, avatar, height, birth_of_date, weight_unit, height_unit, created_at, updated_at, modified_at, avatar_image`
+
 type UserRepository struct {
 	db *sql.DB
 }
@@ -17,52 +29,74 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 func (r *UserRepository) Create(accountID int64, u *domain.User) (int64, error) {
-	result, err := r.db.Exec(
-		`INSERT INTO users (account_id, name, surname, gender, avatar, height, birth_of_date)
-		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		accountID, u.Name, u.Surname, u.Gender, u.Avatar, u.Height, u.BirthOfDate,
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	seq, err := nextSyncSeq(tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := hasLiveProfile(tx, accountID)
+	if err != nil {
+		return 0, err
+	}
+	if existing {
+		return 0, ErrProfileExists
+	}
+	u.UUID = uuid.New()
+	u.Version = 1
+	result, err := tx.Exec(
+		`INSERT INTO users (account_id, uuid, sync_seq, name, surname, gender, avatar, height, birth_of_date, weight_unit, height_unit)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		accountID, u.UUID, seq, u.Name, u.Surname, u.Gender, u.Avatar, u.Height, u.BirthOfDate, u.WeightUnit, u.HeightUnit,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create user: %w", err)
 	}
-	return result.LastInsertId()
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit user: %w", err)
+	}
+	return id, nil
 }
 
 func (r *UserRepository) GetByIDAndAccountID(id, accountID int64) (*domain.User, error) {
-	var u domain.User
-	err := r.db.QueryRow(
-		`SELECT id, name, surname, gender, avatar, height, birth_of_date, created_at, updated_at
-		 FROM users WHERE id = ? AND account_id = ?`, id, accountID,
-	).Scan(&u.ID, &u.Name, &u.Surname, &u.Gender, &u.Avatar, &u.Height, &u.BirthOfDate, &u.CreatedAt, &u.UpdatedAt)
+	u, err := scanUser(r.db.QueryRow(
+		`SELECT `+userColumns+` FROM users WHERE id = ? AND account_id = ? AND deleted_at IS NULL`, id, accountID,
+	))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
-	return &u, nil
+	return u, nil
 }
 
 func (r *UserRepository) GetByAccountID(accountID int64) (*domain.User, error) {
-	var u domain.User
-	err := r.db.QueryRow(
-		`SELECT id, name, surname, gender, avatar, height, birth_of_date, created_at, updated_at
-		 FROM users WHERE account_id = ? LIMIT 1`, accountID,
-	).Scan(&u.ID, &u.Name, &u.Surname, &u.Gender, &u.Avatar, &u.Height, &u.BirthOfDate, &u.CreatedAt, &u.UpdatedAt)
+	u, err := scanUser(r.db.QueryRow(
+		`SELECT `+userColumns+` FROM users WHERE account_id = ? AND deleted_at IS NULL ORDER BY id ASC LIMIT 1`, accountID,
+	))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by account id: %w", err)
 	}
-	return &u, nil
+	return u, nil
 }
 
 func (r *UserRepository) GetAllByAccountID(accountID int64) ([]domain.User, error) {
 	rows, err := r.db.Query(
-		`SELECT id, name, surname, gender, avatar, height, birth_of_date, created_at, updated_at
+		`SELECT `+userColumns+`
 		 FROM users
-		 WHERE account_id = ?
+		 WHERE account_id = ? AND deleted_at IS NULL
 		 ORDER BY id ASC`, accountID,
 	)
 	if err != nil {
@@ -72,18 +106,21 @@ func (r *UserRepository) GetAllByAccountID(accountID int64) ([]domain.User, erro
 
 	var users []domain.User
 	for rows.Next() {
-		var u domain.User
-		if err := rows.Scan(&u.ID, &u.Name, &u.Surname, &u.Gender, &u.Avatar, &u.Height, &u.BirthOfDate, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		u, err := scanUser(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
-		users = append(users, u)
+		users = append(users, *u)
 	}
 	return users, rows.Err()
 }
 
+// UpdateByIDAndAccountID applies fields when the profile is still at version
+// (0 skips the check).
 func (r *UserRepository) UpdateByIDAndAccountID(
 	id int64,
 	accountID int64,
+	version int64,
 	fields map[string]interface{},
 ) error {
 	if len(fields) == 0 {
@@ -93,6 +130,7 @@ func (r *UserRepository) UpdateByIDAndAccountID(
 	allowed := map[string]bool{
 		"name": true, "surname": true, "gender": true,
 		"avatar": true, "height": true, "birth_of_date": true,
+		"weight_unit": true, "height_unit": true, "avatar_image": true,
 	}
 
 	var setClauses []string
@@ -109,12 +147,57 @@ func (r *UserRepository) UpdateByIDAndAccountID(
 		return nil
 	}
 
-	args = append(args, id, accountID)
-	query := "UPDATE users SET " + strings.Join(setClauses, ", ") + " WHERE id = ? AND account_id = ?"
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	_, err := r.db.Exec(query, args...)
+	seq, err := nextSyncSeq(tx, accountID)
+	if err != nil {
+		return err
+	}
+	setClauses = append(setClauses, "version = version + 1", "modified_at = NOW(3)", "sync_seq = ?")
+	args = append(args, seq, id, accountID)
+	query := "UPDATE users SET " + strings.Join(setClauses, ", ") + " WHERE id = ? AND account_id = ? AND deleted_at IS NULL"
+	if version > 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+
+	result, err := tx.Exec(query, args...)
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrVersionMismatch
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user update: %w", err)
+	}
 	return nil
 }
+
+// hasLiveProfile must run under the account's sync sequence lock, which every
+// profile insert holds. Its locking read sees profiles committed by earlier
+// holders whatever snapshot the transaction started with.
+func hasLiveProfile(tx *sql.Tx, accountID int64) (bool, error) {
+	var n int
+	if err := tx.QueryRow(
+		`SELECT COUNT(*) FROM users WHERE account_id = ? AND deleted_at IS NULL FOR SHARE`, accountID,
+	).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to check account user: %w", err)
+	}
+	return n > 0, nil
+}
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	var u domain.User
+	err := row.Scan(&u.ID, &u.UUID, &u.Version, &u.Name, &u.Surname, &u.Gender, &u.Avatar, &u.Height, &u.BirthOfDate,
+		&u.WeightUnit, &u.HeightUnit, &u.CreatedAt, &u.UpdatedAt, &u.ModifiedAt, &u.AvatarImage)
+	if err != nil {
+		return nil, err
+	}
+	u.SetAvatarURL()
+	return &u, nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"log"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+)
+
+// GoalTracker marks a user's active goal achieved once their history reaches
+// it. It runs after every write that can add or change weigh-ins, so reading
+// a goal never has to change it.
+type GoalTracker struct {
+	goalRepo   *repository.GoalRepository
+	metricRepo *repository.MetricRepository
+}
+
+func NewGoalTracker(goalRepo *repository.GoalRepository, metricRepo *repository.MetricRepository) *GoalTracker {
+	return &GoalTracker{goalRepo: goalRepo, metricRepo: metricRepo}
+}
+
+// Check updates the goal on a best-effort basis: the write that triggered it
+// has already been committed, and the next write checks again.
+func (t *GoalTracker) Check(userID int64) {
+	goal, err := t.goalRepo.GetActiveByUserID(userID)
+	if err != nil {
+		log.Printf("[goal] user %d: %v", userID, err)
+		return
+	}
+	if goal == nil {
+		return
+	}
+	metrics, err := t.metricRepo.GetByUserID(userID)
+	if err != nil {
+		log.Printf("[goal] user %d: %v", userID, err)
+		return
+	}
+	series := calc.GoalSeries(goal.Type, calc.WithoutSuspects(metrics))
+	if !calc.GoalProgress(*goal, series, time.Now().UTC()).Reached {
+		return
+	}
+	if err := t.goalRepo.UpdateStatus(goal.ID, domain.GoalStatusAchieved); err != nil {
+		log.Printf("[goal] user %d: %v", userID, err)
+	}
+}
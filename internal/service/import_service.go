@@ -0,0 +1,206 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/importer"
+	"github.com/yusufkecer/body-metrics-backend/internal/repository"
+)
+
+const (
+	progressInterval = 2 * time.Second
+	maxJobReportRows = 200
+)
+
+// importJobStore is the part of the import job repository a background
+// import reports to, so tests can run one without a database.
+type importJobStore interface {
+	Create(job *domain.ImportJob) (int64, error)
+	UpdateProgress(id int64, status string, bytesProcessed, bytesTotal int64) error
+	Complete(id int64, report *domain.ImportReport) error
+	Fail(id int64, message string) error
+}
+
+type ImportService struct {
+	jobRepo         importJobStore
+	metricRepo      *repository.MetricRepository
+	measurementRepo *repository.MeasurementRepository
+	goals           *GoalTracker
+}
+
+func NewImportService(
+	jobRepo *repository.ImportJobRepository,
+	metricRepo *repository.MetricRepository,
+	measurementRepo *repository.MeasurementRepository,
+	goals *GoalTracker,
+) *ImportService {
+	return &ImportService{jobRepo: jobRepo, metricRepo: metricRepo, measurementRepo: measurementRepo, goals: goals}
+}
+
+// Save stores parsed records, deduplicating weigh-ins against user_metrics
+// and body composition values against user_measurements, then recomputes the
+// derived metric fields over the whole history.
+func (s *ImportService) Save(user *domain.User, records []importer.Record, invalid []domain.ImportRow) (domain.ImportReport, error) {
+	metrics, metricIndex := importer.ToMetrics(records)
+	metricDup, err := s.metricRepo.Import(user.ID, metrics, func(all []domain.UserMetric) {
+		calc.DeriveHistory(all, user)
+	})
+	if err != nil {
+		return domain.ImportReport{}, err
+	}
+	s.goals.Check(user.ID)
+
+	measurements, measurementIndex := importer.ToMeasurements(records)
+	var measurementDup []bool
+	if len(measurements) > 0 {
+		if measurementDup, err = s.measurementRepo.Import(user.ID, measurements); err != nil {
+			return domain.ImportReport{}, err
+		}
+	}
+
+	// A record is a duplicate only when every value it carried already existed.
+	duplicates := make([]bool, len(records))
+	for i := range duplicates {
+		duplicates[i] = true
+	}
+	for i, dup := range metricDup {
+		duplicates[metricIndex[i]] = duplicates[metricIndex[i]] && dup
+	}
+	for i, dup := range measurementDup {
+		duplicates[measurementIndex[i]] = duplicates[measurementIndex[i]] && dup
+	}
+
+	return importer.BuildReport(records, invalid, duplicates), nil
+}
+
+// StartFile queues a background import of an export saved at path. The file
+// is removed once the job finishes.
+func (s *ImportService) StartFile(user *domain.User, src importer.Source, path string) (*domain.ImportJob, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.ImportJob{
+		UserID:     user.ID,
+		Source:     src.Name(),
+		Status:     domain.ImportJobQueued,
+		BytesTotal: info.Size(),
+	}
+	if job.ID, err = s.jobRepo.Create(job); err != nil {
+		return nil, err
+	}
+
+	go s.runFile(job.ID, *user, src, path)
+	return job, nil
+}
+
+func (s *ImportService) runFile(jobID int64, user domain.User, src importer.Source, path string) {
+	defer os.Remove(path)
+
+	fail := func(err error) {
+		log.Printf("[import] job %d failed: %v", jobID, err)
+		if err := s.jobRepo.Fail(jobID, err.Error()); err != nil {
+			log.Printf("[import] job %d: %v", jobID, err)
+		}
+	}
+	// A parser bug on one upload must not take the server down or leave the
+	// job running forever.
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("[import] job %d panicked: %v\n%s", jobID, p, debug.Stack())
+			fail(fmt.Errorf("import failed unexpectedly"))
+		}
+	}()
+
+	stream, total, err := importer.Open(src, path)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer stream.Close()
+
+	counter := &countingReader{r: stream}
+	done := make(chan struct{})
+	stopProgress := sync.OnceFunc(func() { close(done) })
+	defer stopProgress()
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				processed := counter.n.Load()
+				if processed > total {
+					processed = total
+				}
+				if err := s.jobRepo.UpdateProgress(jobID, domain.ImportJobRunning, processed, total); err != nil {
+					log.Printf("[import] job %d: %v", jobID, err)
+				}
+			}
+		}
+	}()
+
+	if err := s.jobRepo.UpdateProgress(jobID, domain.ImportJobRunning, 0, total); err != nil {
+		log.Printf("[import] job %d: %v", jobID, err)
+	}
+
+	records, invalid, err := src.Parse(counter, time.Now().UTC())
+	stopProgress()
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	report, err := s.Save(&user, records, invalid)
+	if err != nil {
+		fail(fmt.Errorf("failed to save imported records: %w", err))
+		return
+	}
+
+	report.Rows = compactRows(report.Rows)
+	if err := s.jobRepo.Complete(jobID, &report); err != nil {
+		log.Printf("[import] job %d: %v", jobID, err)
+		return
+	}
+	log.Printf("[import] job %d completed: %d imported, %d duplicates, %d invalid",
+		jobID, report.Imported, report.Duplicates, report.Invalid)
+}
+
+// compactRows keeps only the rows worth reporting for large imports: those
+// that were not imported, capped at maxJobReportRows.
+func compactRows(rows []domain.ImportRow) []domain.ImportRow {
+	out := make([]domain.ImportRow, 0)
+	for _, row := range rows {
+		if row.Status == domain.ImportRowImported {
+			continue
+		}
+		if len(out) == maxJobReportRows {
+			break
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
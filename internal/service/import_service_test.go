@@ -0,0 +1,86 @@
+package service
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+	"github.com/yusufkecer/body-metrics-backend/internal/importer"
+)
+
+type fakeJobs struct {
+	mu       sync.Mutex
+	failed   map[int64]string
+	complete map[int64]*domain.ImportReport
+}
+
+func newFakeJobs() *fakeJobs {
+	return &fakeJobs{failed: make(map[int64]string), complete: make(map[int64]*domain.ImportReport)}
+}
+
+func (f *fakeJobs) Create(job *domain.ImportJob) (int64, error) { return 1, nil }
+
+func (f *fakeJobs) UpdateProgress(id int64, status string, bytesProcessed, bytesTotal int64) error {
+	return nil
+}
+
+func (f *fakeJobs) Complete(id int64, report *domain.ImportReport) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.complete[id] = report
+	return nil
+}
+
+func (f *fakeJobs) Fail(id int64, message string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failed[id] = message
+	return nil
+}
+
+type panickingSource struct{}
+
+func (panickingSource) Name() string { return "panicking" }
+
+func (panickingSource) Parse(r io.Reader, now time.Time) ([]importer.Record, []domain.ImportRow, error) {
+	panic("parser bug")
+}
+
+func TestRunFileFailsJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     importer.Source
+		content string
+		want    string
+	}{
+		{"malformed export", importer.AppleHealth{}, `<HealthData><Record type="HKQuantityTypeIdentifierBodyMass" value="7`, "invalid health export"},
+		{"parser panic", panickingSource{}, "anything", "import failed unexpectedly"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "upload")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			jobs := newFakeJobs()
+			s := &ImportService{jobRepo: jobs}
+
+			s.runFile(1, domain.User{ID: 7}, tt.src, path)
+
+			if msg, ok := jobs.failed[1]; !ok || !strings.Contains(msg, tt.want) {
+				t.Errorf("failure = %q (recorded %v), want it to contain %q", msg, ok, tt.want)
+			}
+			if len(jobs.complete) != 0 {
+				t.Error("job was completed")
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("upload was not removed: %v", err)
+			}
+		})
+	}
+}
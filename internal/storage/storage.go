@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+type Info struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Store keeps binary objects such as photos under slash-separated keys. The
+// local filesystem store is used today; an S3-compatible store can implement
+// the same interface.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,195 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	Kilogram = "kg"
+	Pound    = "lb"
+	Stone    = "st"
+
+	Centimeter = "cm"
+	Inch       = "in"
+
+	kgPerPound = 0.45359237
+	kgPerStone = 6.35029318
+	cmPerInch  = 2.54
+)
+
+type Preference struct {
+	Weight string
+	Height string
+}
+
+var Default = Preference{Weight: Kilogram, Height: Centimeter}
+
+func ValidWeightUnit(unit string) bool {
+	return unit == Kilogram || unit == Pound || unit == Stone
+}
+
+func ValidHeightUnit(unit string) bool {
+	return unit == Centimeter || unit == Inch
+}
+
+func (p Preference) Validate() error {
+	if !ValidWeightUnit(p.Weight) {
+		return fmt.Errorf("weight_unit must be kg, lb or st")
+	}
+	if !ValidHeightUnit(p.Height) {
+		return fmt.Errorf("height_unit must be cm or in")
+	}
+	return nil
+}
+
+func round(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
+
+// WeightToKG normalises an input weight to kilograms, kept to 0.01 kg.
+func WeightToKG(v float64, unit string) float64 {
+	switch unit {
+	case Pound:
+		return round(v*kgPerPound, 2)
+	case Stone:
+		return round(v*kgPerStone, 2)
+	}
+	return v
+}
+
+// WeightFromKG converts a stored weight for display: pounds to 0.1 lb and
+// stones to 0.01 st. Kilograms are returned as stored.
+func WeightFromKG(kg float64, unit string) float64 {
+	switch unit {
+	case Pound:
+		return round(kg/kgPerPound, 1)
+	case Stone:
+		return round(kg/kgPerStone, 2)
+	}
+	return kg
+}
+
+// HeightToCM normalises an input height to whole centimetres. Imperial heights
+// are given in total inches and may be fractional (5'10.5" is 70.5).
+func HeightToCM(v float64, unit string) float64 {
+	if unit == Inch {
+		return math.Round(v * cmPerInch)
+	}
+	return math.Round(v)
+}
+
+// HeightFromCM converts a stored height for display. Inches are rounded to the
+// nearest half inch: heights are kept in whole centimetres (under half an
+// inch), so any height entered to the half inch reads back unchanged.
+func HeightFromCM(cm float64, unit string) float64 {
+	if unit == Inch {
+		return math.Round(cm/cmPerInch*2) / 2
+	}
+	return cm
+}
+
+// FormatFeetInches writes total inches as feet and inches, such as 5'10.5".
+func FormatFeetInches(inches float64) string {
+	feet := math.Floor(inches / 12)
+	rest := inches - feet*12
+	return fmt.Sprintf(`%d'%s"`, int(feet), strconv.FormatFloat(round(rest, 1), 'f', -1, 64))
+}
+
+var feetInchesPattern = regexp.MustCompile(`^(\d+)\s*(?:'|ft)\s*(?:(\d+(?:\.\d+)?)\s*(?:"|''|in)?)?$`)
+
+// ParseFeetInches reads a height such as 5'10.5", 5' 10", 5ft 10.5in or 6' and
+// returns it in total inches.
+func ParseFeetInches(s string) (float64, error) {
+	match := feetInchesPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return 0, fmt.Errorf("height_ft_in must look like 5'10.5\"")
+	}
+	feet, _ := strconv.ParseFloat(match[1], 64)
+	var inches float64
+	if match[2] != "" {
+		inches, _ = strconv.ParseFloat(match[2], 64)
+	}
+	if inches >= 12 {
+		return 0, fmt.Errorf("height_ft_in must have fewer than 12 inches")
+	}
+	return feet*12 + inches, nil
+}
+
+// feetInches is the ft-in form of a stored height shown alongside inches.
+func feetInches(cm float64, unit string) *string {
+	if unit != Inch || cm <= 0 {
+		return nil
+	}
+	s := FormatFeetInches(HeightFromCM(cm, Inch))
+	return &s
+}
+
+func convertWeight(v *float64, convert func(float64) float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	out := convert(*v)
+	return &out
+}
+
+// MetricToSI converts a submitted metric from p, which callers resolve with
+// any units named in the request body, and clears its unit fields.
+func MetricToSI(m *domain.UserMetric, p Preference) {
+	m.Weight = convertWeight(m.Weight, func(v float64) float64 { return WeightToKG(v, p.Weight) })
+	m.WeightDiff = convertWeight(m.WeightDiff, func(v float64) float64 { return WeightToKG(v, p.Weight) })
+	if m.Height > 0 {
+		m.Height = HeightToCM(m.Height, p.Height)
+	}
+	m.WeightUnit, m.HeightUnit, m.HeightFtIn = "", "", nil
+}
+
+func MetricFromSI(m *domain.UserMetric, p Preference) {
+	m.Weight = convertWeight(m.Weight, func(v float64) float64 { return WeightFromKG(v, p.Weight) })
+	m.WeightDiff = convertWeight(m.WeightDiff, func(v float64) float64 { return WeightFromKG(v, p.Weight) })
+	m.HeightFtIn = feetInches(m.Height, p.Height)
+	m.Height = HeightFromCM(m.Height, p.Height)
+	m.WeightUnit, m.HeightUnit = p.Weight, p.Height
+}
+
+func UserFromSI(u *domain.User, p Preference) {
+	if u.Height != nil {
+		u.HeightFtIn = feetInches(*u.Height, p.Height)
+		h := HeightFromCM(*u.Height, p.Height)
+		u.Height = &h
+	}
+}
+
+func TrendFromSI(points []domain.TrendPoint, unit string) {
+	for i := range points {
+		points[i].Weight = WeightFromKG(points[i].Weight, unit)
+		points[i].MovingAverage = WeightFromKG(points[i].MovingAverage, unit)
+		points[i].Trend = WeightFromKG(points[i].Trend, unit)
+	}
+}
+
+func SummaryFromSI(s *domain.MetricSummary, unit string) {
+	toUnit := func(v float64) float64 { return WeightFromKG(v, unit) }
+	for _, w := range []**float64{&s.StartWeight, &s.LatestWeight, &s.MinWeight, &s.MaxWeight, &s.AverageWeight, &s.WeightChange} {
+		*w = convertWeight(*w, toUnit)
+	}
+	s.WeightUnit = unit
+}
+
+func MaintenanceFromSI(e *domain.MaintenanceEstimate, unit string) {
+	toUnit := func(v float64) float64 { return WeightFromKG(v, unit) }
+	for _, w := range []**float64{&e.TrendStart, &e.TrendEnd, &e.WeightChange, &e.WeeklyWeightChange} {
+		*w = convertWeight(*w, toUnit)
+	}
+	for i := range e.Days {
+		e.Days[i].Weight = convertWeight(e.Days[i].Weight, toUnit)
+		e.Days[i].TrendWeight = convertWeight(e.Days[i].TrendWeight, toUnit)
+	}
+	e.WeightUnit = unit
+}
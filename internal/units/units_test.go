@@ -0,0 +1,44 @@
+package units
+
+import "testing"
+
+func TestHalfInchHeightsRoundTrip(t *testing.T) {
+	for inches := 36.0; inches <= 96; inches += 0.5 {
+		cm := HeightToCM(inches, Inch)
+		if got := HeightFromCM(cm, Inch); got != inches {
+			t.Errorf("%v in -> %v cm -> %v in", inches, cm, got)
+		}
+	}
+}
+
+func TestParseFeetInches(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{`5'10.5"`, 70.5, false},
+		{`5' 10"`, 70, false},
+		{`5ft 10.5in`, 70.5, false},
+		{`6'`, 72, false},
+		{` 5'0" `, 60, false},
+		{`5'12"`, 0, true},
+		{`70`, 0, true},
+		{`five feet`, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFeetInches(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFeetInches(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatFeetInches(t *testing.T) {
+	tests := map[float64]string{70.5: `5'10.5"`, 72: `6'0"`, 59.5: `4'11.5"`}
+	for inches, want := range tests {
+		if got := FormatFeetInches(inches); got != want {
+			t.Errorf("FormatFeetInches(%v) = %s, want %s", inches, got, want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package uuid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// New returns a random (version 4) UUID in canonical lower-case form.
+func New() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// Normalize lower-cases s and reports whether it is a canonical UUID.
+func Normalize(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return s, pattern.MatchString(s)
+}
@@ -0,0 +1,166 @@
+// Package validation holds the metric rules shared by every write path: the
+// metric endpoints, sync and the importers.
+package validation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/calc"
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+const (
+	MinWeightKG          = 1
+	MaxWeightKG          = 650
+	MinHeightCM          = 40
+	MaxHeightCM          = 272
+	minAdultWeightKG     = 25
+	maxPlausibleWeightKG = 300
+	minPlausibleBMI      = 12
+	maxPlausibleBMI      = 70
+	adultAge             = 20
+	heightToleranceCM    = 5
+	// FutureDateTolerance lets entries dated "today" in time zones ahead of
+	// UTC start up to 14 hours after the server's current UTC time.
+	FutureDateTolerance = 14 * time.Hour
+)
+
+var bodyMetrics = map[string]bool{
+	domain.BodyMetricUnderweight: true,
+	domain.BodyMetricNormal:      true,
+	domain.BodyMetricOverweight:  true,
+	domain.BodyMetricObese:       true,
+}
+
+func fail(list *[]domain.FieldError, field, code, format string, args ...interface{}) {
+	*list = append(*list, domain.FieldError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// Metric checks a submitted metric in SI units against its profile before
+// derived fields are filled in. errs are values that can never be stored;
+// warnings are implausible but possible values that are stored only once the
+// client confirms them. The note and tags of m are normalised in place.
+func Metric(m *domain.UserMetric, user *domain.User, now time.Time) (errs, warnings []domain.FieldError) {
+	var birth *time.Time
+	if user.BirthOfDate != nil {
+		if t, err := calc.ParseDate(*user.BirthOfDate); err == nil {
+			birth = &t
+		}
+	}
+	if errs := MetricValues(m, birth, now); len(errs) > 0 {
+		return errs, nil
+	}
+
+	height := m.Height
+	if height == 0 && user.Height != nil {
+		height = *user.Height
+	}
+	if m.Weight != nil && height == 0 {
+		fail(&errs, "height", domain.FieldRequired, "height is required when the profile has none")
+		return errs, nil
+	}
+
+	if m.Weight != nil {
+		switch w := *m.Weight; {
+		case w > maxPlausibleWeightKG:
+			fail(&warnings, "weight", domain.FieldImplausible, "weight above %d kg is unusual", maxPlausibleWeightKG)
+		case w < minAdultWeightKG && (birth == nil || calc.AgeYears(*birth, now) >= adultAge):
+			fail(&warnings, "weight", domain.FieldImplausible, "weight below %d kg is unusual for an adult", minAdultWeightKG)
+		}
+	}
+	// Adults stop growing, so a height far from the profile is likely a typo.
+	if m.Height != 0 && user.Height != nil && birth != nil && calc.AgeYears(*birth, now) >= adultAge &&
+		math.Abs(m.Height-*user.Height) > heightToleranceCM {
+		fail(&warnings, "height", domain.FieldInconsistent, "height differs from the profile height of %.0f cm", *user.Height)
+	}
+	if m.Weight != nil {
+		if bmi := calc.BMI(*m.Weight, height); bmi < minPlausibleBMI || bmi > maxPlausibleBMI {
+			fail(&warnings, "bmi", domain.FieldImplausible, "weight and height give an unusual BMI of %.1f", bmi)
+		}
+	}
+	return nil, warnings
+}
+
+// MetricValues returns the errors that do not depend on the rest of the
+// profile: the date, weight and height ranges, body_metric, note and tags.
+// birth is optional. The note and tags of m are normalised in place.
+func MetricValues(m *domain.UserMetric, birth *time.Time, now time.Time) []domain.FieldError {
+	var errs []domain.FieldError
+
+	date, err := calc.ParseDate(m.Date)
+	switch {
+	case m.Date == "":
+		fail(&errs, "date", domain.FieldRequired, "date is required")
+	case err != nil:
+		fail(&errs, "date", domain.FieldBadFormat, "date is not a recognised date")
+	case date.After(now.Add(FutureDateTolerance)):
+		fail(&errs, "date", domain.FieldInFuture, "date must not be in the future")
+	case birth != nil && date.Before(*birth):
+		fail(&errs, "date", domain.FieldOutOfRange, "date must not be before the birth date")
+	}
+
+	if m.Weight == nil && m.Height == 0 {
+		fail(&errs, "weight", domain.FieldRequired, "weight or height is required")
+	}
+	if m.Weight != nil {
+		if w := *m.Weight; math.IsNaN(w) || w < MinWeightKG || w > MaxWeightKG {
+			fail(&errs, "weight", domain.FieldOutOfRange, "weight must be between %d and %d kg", MinWeightKG, MaxWeightKG)
+		}
+	}
+	if m.Height != 0 && (m.Height < MinHeightCM || m.Height > MaxHeightCM) {
+		fail(&errs, "height", domain.FieldOutOfRange, "height must be between %d and %d cm", MinHeightCM, MaxHeightCM)
+	}
+	if m.BodyMetric != nil && !bodyMetrics[*m.BodyMetric] {
+		fail(&errs, "body_metric", domain.FieldNotAllowed, "unknown body_metric %q", *m.BodyMetric)
+	}
+	if m.Note != nil {
+		if note := strings.TrimSpace(*m.Note); note == "" {
+			m.Note = nil
+		} else if utf8.RuneCountInString(note) > domain.MaxNoteLength {
+			fail(&errs, "note", domain.FieldTooLong, "note must be at most %d characters", domain.MaxNoteLength)
+		} else {
+			m.Note = &note
+		}
+	}
+	tags, tagErrs := Tags(m.Tags)
+	m.Tags = tags
+	return append(errs, tagErrs...)
+}
+
+// Tags lower-cases and de-duplicates tag names, keeping their order.
+func Tags(raw []string) ([]string, []domain.FieldError) {
+	var tags []string
+	var errs []domain.FieldError
+	seen := make(map[string]bool, len(raw))
+	for _, r := range raw {
+		name, ok := domain.NormalizeTag(r)
+		if !ok {
+			errs = append(errs, InvalidTag("tags", r))
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			tags = append(tags, name)
+		}
+	}
+	if len(tags) > domain.MaxTagsPerMetric {
+		errs = append(errs, domain.FieldError{
+			Field:   "tags",
+			Code:    domain.FieldTooLong,
+			Message: fmt.Sprintf("at most %d tags are allowed per entry", domain.MaxTagsPerMetric),
+		})
+	}
+	return tags, errs
+}
+
+func InvalidTag(field, name string) domain.FieldError {
+	return domain.FieldError{
+		Field:   field,
+		Code:    domain.FieldBadFormat,
+		Message: fmt.Sprintf("tag %q must be 1-32 letters, digits, '-' or '_'", name),
+	}
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yusufkecer/body-metrics-backend/internal/domain"
+)
+
+func TestMetricValues(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	weight := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name   string
+		metric domain.UserMetric
+		field  string
+		code   string
+	}{
+		{"valid", domain.UserMetric{Date: "2024-05-01", Weight: weight(72.4), Height: 180}, "", ""},
+		{"height only", domain.UserMetric{Date: "2024-04-30", Height: 272}, "", ""},
+		{"missing date", domain.UserMetric{Weight: weight(72.4)}, "date", domain.FieldRequired},
+		{"future date", domain.UserMetric{Date: "2024-05-03", Weight: weight(72.4)}, "date", domain.FieldInFuture},
+		{"no values", domain.UserMetric{Date: "2024-05-01"}, "weight", domain.FieldRequired},
+		{"weight too high", domain.UserMetric{Date: "2024-05-01", Weight: weight(651)}, "weight", domain.FieldOutOfRange},
+		{"height too low", domain.UserMetric{Date: "2024-05-01", Height: 39}, "height", domain.FieldOutOfRange},
+		{"bad tag", domain.UserMetric{Date: "2024-05-01", Weight: weight(72.4), Tags: []string{"no spaces"}}, "tags", domain.FieldBadFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := MetricValues(&tt.metric, nil, now)
+			if tt.field == "" {
+				if len(errs) != 0 {
+					t.Errorf("errors = %v, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.field || errs[0].Code != tt.code {
+				t.Errorf("errors = %v, want %s/%s", errs, tt.field, tt.code)
+			}
+		})
+	}
+}